@@ -0,0 +1,45 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+# Benchmarks a cached endpoint with apache bench and plots the response
+# times against the committed baseline (with-cache.data) with
+# payload-benchmark.p. The baseline itself is never overwritten.
+#
+# Every request is made twice: once as a plain client and once accepting
+# gzip, which is served straight from the cached payload. Allocations per
+# request are read from the Go runtime metrics exposed on /metrics.
+#
+#  ./benchmark.sh [URL]
+
+URL="${1-http://localhost:8080/api/v1/recipes}"
+METRICS="${METRICS-$(echo "$URL" | grep -Eo '^https?://[^/]+')/metrics}"
+REQUESTS="${REQUESTS-2000}"
+CONCURRENCY="${CONCURRENCY-1}"
+
+allocated() {
+	curl -s "$METRICS" | awk '/^go_memstats_alloc_bytes_total / { printf "%.0f", $2 }'
+}
+
+run() {
+	local name="$1"
+	shift
+	# warm the cache so every measured request is a hit
+	curl -s -o /dev/null "$@" "$URL"
+
+	local before after
+	before=$(allocated)
+	ab -q -n "$REQUESTS" -c "$CONCURRENCY" -g "$name.data" "$@" "$URL" |
+		grep -E '^(Document Length|Requests per second|Time per request)'
+	after=$(allocated)
+	# /metrics scrapes allocate too, but only twice per run
+	echo "Allocated per request: $(((after - before) / REQUESTS)) bytes"
+	echo
+}
+
+echo "== plain =="
+run with-encoded-cache
+echo "== gzip =="
+run with-encoded-cache-gzip -H "Accept-Encoding: gzip"
+
+gnuplot payload-benchmark.p
+echo "Plotted payload-benchmark.png"
@@ -0,0 +1,128 @@
+package bulk
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// CSVHeader lists the recipe columns in export order. Imports match
+// columns by name, so files may reorder or omit them.
+var CSVHeader = []string{
+	"id", "name", "tags", "ingredients", "instructions", "servings",
+	"calories", "fat", "satfat", "carbs", "fiber", "sugar", "protein", "cookTime", "rating", "publishedAt",
+}
+
+// listSeparator joins tags and ingredients inside a single CSV cell;
+// ingredients commonly contain commas.
+const listSeparator = "|"
+
+// ToCSV flattens a recipe into a row matching CSVHeader.
+func ToCSV(recipe models.Recipe) []string {
+	publishedAt := ""
+	if !recipe.PublishedAt.IsZero() {
+		publishedAt = recipe.PublishedAt.Format(time.RFC3339)
+	}
+	ingredients := make([]string, 0, len(recipe.Ingredients))
+	for _, line := range recipe.Ingredients {
+		ingredients = append(ingredients, strings.TrimSpace(line))
+	}
+	return []string{
+		recipe.ID.Hex(),
+		recipe.Name,
+		strings.Join(recipe.Tags, listSeparator),
+		strings.Join(ingredients, listSeparator),
+		recipe.Instructions,
+		strconv.Itoa(recipe.Servings),
+		strconv.Itoa(recipe.Calories),
+		strconv.Itoa(recipe.Fat),
+		strconv.Itoa(recipe.SatFat),
+		strconv.Itoa(recipe.Carbs),
+		strconv.Itoa(recipe.Fiber),
+		strconv.Itoa(recipe.Sugar),
+		strconv.Itoa(recipe.Protein),
+		strconv.Itoa(recipe.CookTime),
+		strconv.FormatFloat(recipe.Rating, 'f', -1, 64),
+		publishedAt,
+	}
+}
+
+// FromCSV builds a recipe from a row, using header to locate columns.
+func FromCSV(header []string, row []string) (models.Recipe, error) {
+	var recipe models.Recipe
+	if len(row) != len(header) {
+		return recipe, fmt.Errorf("expected %d columns, got %d", len(header), len(row))
+	}
+
+	integers := map[string]*int{
+		"servings": &recipe.Servings,
+		"calories": &recipe.Calories,
+		"fat":      &recipe.Fat,
+		"satfat":   &recipe.SatFat,
+		"carbs":    &recipe.Carbs,
+		"fiber":    &recipe.Fiber,
+		"sugar":    &recipe.Sugar,
+		"protein":  &recipe.Protein,
+		"cookTime": &recipe.CookTime,
+	}
+	for i, column := range header {
+		value := strings.TrimSpace(row[i])
+		if value == "" {
+			continue
+		}
+		switch column {
+		case "id":
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return recipe, fmt.Errorf("id: %w", err)
+			}
+			recipe.ID = id
+		case "name":
+			recipe.Name = value
+		case "tags":
+			recipe.Tags = splitList(value)
+		case "ingredients":
+			recipe.Ingredients = splitList(value)
+		case "instructions":
+			recipe.Instructions = row[i]
+		case "rating":
+			// ParseFloat also accepts "NaN" and "Inf"
+			rating, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(rating) || math.IsInf(rating, 0) {
+				return recipe, fmt.Errorf("rating: %q is not a number", value)
+			}
+			recipe.Rating = rating
+		case "publishedAt":
+			publishedAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return recipe, fmt.Errorf("publishedAt: %w", err)
+			}
+			recipe.PublishedAt = publishedAt
+		default:
+			if field, ok := integers[column]; ok {
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return recipe, fmt.Errorf("%s: %q is not an integer", column, value)
+				}
+				*field = n
+			}
+		}
+	}
+	return recipe, nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,34 @@
+package bulk
+
+import "testing"
+
+func TestFromCSVRating(t *testing.T) {
+	header := []string{"name", "ingredients", "rating"}
+	for _, tc := range []struct {
+		rating string
+		want   float64
+		valid  bool
+	}{
+		{"4.5", 4.5, true},
+		{"", 0, true},
+		{"7", 7, false},
+		{"NaN", 0, false},
+		{"nan", 0, false},
+		{"Inf", 0, false},
+		{"-Infinity", 0, false},
+		{"1e999", 0, false},
+		{"four", 0, false},
+	} {
+		recipe, err := FromCSV(header, []string{"Pancakes", "1 cup flour|2 eggs", tc.rating})
+		if err == nil {
+			err = recipe.Validate()
+		}
+		if (err == nil) != tc.valid {
+			t.Errorf("importing rating %q: err = %v, want valid %v", tc.rating, err, tc.valid)
+			continue
+		}
+		if tc.valid && recipe.Rating != tc.want {
+			t.Errorf("importing rating %q: Rating = %v, want %v", tc.rating, recipe.Rating, tc.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// internal prefixes the keys the cache keeps for its own bookkeeping:
+// tag sets, fill locks and the invalidation clock and stamps.
+const internal = "cache:"
+
+// scanCount is how many keys each SCAN step asks for, and how many keys
+// are deleted per command when flushing.
+const scanCount = 500
+
+// KeyInfo describes an entry cached in Redis.
+type KeyInfo struct {
+	Key   string
+	Bytes int64
+	// TTL is how long the entry is kept, including the stale window.
+	TTL   time.Duration
+	Stale bool
+}
+
+// LocalStats reports the entries held in process and their total size.
+func (cache *Cache) LocalStats() (int, int) {
+	cache.local.mu.Lock()
+	defer cache.local.mu.Unlock()
+	return len(cache.local.items), cache.local.bytes
+}
+
+// Keys returns up to limit entries whose keys start with prefix.
+func (cache *Cache) Keys(prefix string, limit int) ([]KeyInfo, error) {
+	if !cache.Available() {
+		return nil, ErrUnavailable
+	}
+	keys, err := cache.scan(prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := cache.client.Pipeline()
+	defer pipe.Close()
+	sizes := make([]*redis.IntCmd, len(keys))
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		sizes[i] = pipe.StrLen(key)
+		ttls[i] = pipe.PTTL(key)
+	}
+	if _, err := pipe.Exec(); cache.observe(err) != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	info := make([]KeyInfo, 0, len(keys))
+	for i, key := range keys {
+		// -2ms: the key expired since it was scanned
+		ttl := ttls[i].Val()
+		if ttl == -2*time.Millisecond {
+			continue
+		}
+		info = append(info, KeyInfo{
+			Key:   key,
+			Bytes: sizes[i].Val(),
+			TTL:   ttl,
+			Stale: ttl >= 0 && ttl <= cache.TTL.Stale,
+		})
+	}
+	return info, nil
+}
+
+// Flush deletes every entry whose key starts with prefix, or the whole
+// cache for an empty prefix, and returns how many entries were deleted.
+func (cache *Cache) Flush(prefix string) (int, error) {
+	if !cache.Available() {
+		return 0, ErrUnavailable
+	}
+	keys, err := cache.scan(prefix, 0)
+	if err != nil {
+		return 0, err
+	}
+	if prefix == "" {
+		// tag sets would only point at deleted entries
+		tags, err := cache.scanAll(internal + "tag:")
+		if err != nil {
+			return 0, err
+		}
+		if err := cache.remove(tags); err != nil {
+			return 0, err
+		}
+		cache.local.clear()
+	}
+	if err := cache.remove(keys); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// FlushTags deletes every entry recorded under any of tags and returns
+// how many entries were deleted.
+func (cache *Cache) FlushTags(tags ...string) (int, error) {
+	if !cache.Available() {
+		return 0, ErrUnavailable
+	}
+	pipe := cache.client.Pipeline()
+	defer pipe.Close()
+	members := make([]*redis.StringSliceCmd, len(tags))
+	for i, tag := range tags {
+		members[i] = pipe.SMembers(tagKey(tag))
+	}
+	if _, err := pipe.Exec(); cache.observe(err) != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, cmd := range members {
+		for _, key := range cmd.Val() {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sets := make([]string, len(tags))
+	for i, tag := range tags {
+		sets[i] = tagKey(tag)
+	}
+	if err := cache.remove(append(keys, sets...)); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// scan returns up to limit entry keys starting with prefix, leaving out
+// the cache's own bookkeeping keys. A limit of 0 returns every key.
+func (cache *Cache) scan(prefix string, limit int) ([]string, error) {
+	all, err := cache.scanAll(prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(all))
+	for _, key := range all {
+		if strings.HasPrefix(key, internal) {
+			continue
+		}
+		keys = append(keys, key)
+		if len(keys) == limit {
+			break
+		}
+	}
+	return keys, nil
+}
+
+func (cache *Cache) scanAll(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	var cursor uint64
+	for {
+		batch, next, err := cache.client.Scan(cursor, escapePattern(prefix)+"*", scanCount).Result()
+		if cache.observe(err) != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		if cursor = next; cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// remove deletes keys in batches and evicts them from every replica's
+// in-process tier.
+func (cache *Cache) remove(keys []string) error {
+	for start := 0; start < len(keys); start += scanCount {
+		end := start + scanCount
+		if end > len(keys) {
+			end = len(keys)
+		}
+		cache.evict(keys[start:end])
+		if err := cache.observe(cache.client.Del(keys[start:end]...).Err()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapePattern escapes the glob characters of a SCAN pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrUnavailable is returned instead of calling Redis while the breaker
+// is open.
+var ErrUnavailable = errors.New("cache: redis unavailable")
+
+// breaker bypasses Redis after consecutive failures until a background
+// probe finds it reachable again.
+//
+// While it is open the cache runs on its in-process tier alone. Tags
+// invalidated meanwhile are held as pending and invalidated in Redis
+// once it is back, so entries written before the outage are not served
+// after it.
+type breaker struct {
+	mu        sync.Mutex
+	open      bool
+	failures  int
+	threshold int
+	interval  time.Duration
+	// pending tags and keys that could not be invalidated in Redis
+	tags map[string]struct{}
+	keys map[string]struct{}
+}
+
+func newBreaker(threshold int, interval time.Duration) *breaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &breaker{
+		threshold: threshold,
+		interval:  interval,
+		tags:      make(map[string]struct{}),
+		keys:      make(map[string]struct{}),
+	}
+}
+
+// Available reports whether the cache is using Redis.
+func (cache *Cache) Available() bool {
+	cache.breaker.mu.Lock()
+	defer cache.breaker.mu.Unlock()
+	return !cache.breaker.open
+}
+
+// Trip opens the breaker, e.g. when Redis is unreachable at startup.
+func (cache *Cache) Trip() {
+	cache.breaker.mu.Lock()
+	defer cache.breaker.mu.Unlock()
+	cache.trip()
+}
+
+// trip opens the breaker and starts probing Redis. The caller holds the
+// breaker's lock.
+func (cache *Cache) trip() {
+	if cache.breaker.open {
+		return
+	}
+	cache.breaker.open = true
+	available.Set(0)
+	trips.Inc()
+	log.Warn("Redis is unavailable, serving without the shared cache")
+	go cache.probe()
+}
+
+// observe records the outcome of a Redis call and returns its error.
+func (cache *Cache) observe(err error) error {
+	cache.breaker.mu.Lock()
+	defer cache.breaker.mu.Unlock()
+	if err == nil || err == redis.Nil {
+		cache.breaker.failures = 0
+		return err
+	}
+	cache.breaker.failures++
+	if cache.breaker.failures >= cache.breaker.threshold {
+		cache.trip()
+	}
+	return err
+}
+
+// probe pings Redis until it answers, then closes the breaker and
+// replays the invalidations missed in the meantime.
+func (cache *Cache) probe() {
+	ticker := time.NewTicker(cache.breaker.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := cache.client.Ping().Err(); err != nil {
+			continue
+		}
+		cache.breaker.mu.Lock()
+		cache.breaker.open = false
+		cache.breaker.failures = 0
+		cache.breaker.mu.Unlock()
+		available.Set(1)
+		log.Info("Redis is available again")
+
+		// invalidate what was written while Redis was down
+		if err := cache.Invalidate(); err != nil {
+			log.Error(err)
+		}
+		return
+	}
+}
+
+// postpone records tags and keys to invalidate once Redis is back. The
+// in-process tier can't tell which entries carry the tags, so it is
+// cleared.
+func (cache *Cache) postpone(tags []string, keys []string) {
+	cache.local.clear()
+	cache.breaker.mu.Lock()
+	defer cache.breaker.mu.Unlock()
+	for _, tag := range tags {
+		cache.breaker.tags[tag] = struct{}{}
+	}
+	for _, key := range keys {
+		cache.breaker.keys[key] = struct{}{}
+	}
+}
+
+// takePending returns and forgets the postponed tags and keys.
+func (cache *Cache) takePending() ([]string, []string) {
+	cache.breaker.mu.Lock()
+	defer cache.breaker.mu.Unlock()
+	tags := make([]string, 0, len(cache.breaker.tags))
+	for tag := range cache.breaker.tags {
+		tags = append(tags, tag)
+	}
+	keys := make([]string, 0, len(cache.breaker.keys))
+	for key := range cache.breaker.keys {
+		keys = append(keys, key)
+	}
+	cache.breaker.tags = make(map[string]struct{})
+	cache.breaker.keys = make(map[string]struct{})
+	return tags, keys
+}
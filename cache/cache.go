@@ -0,0 +1,482 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrMiss is returned by Get when a key is not cached.
+var ErrMiss = errors.New("cache: miss")
+
+// invalidations carries the keys dropped by one replica so the others can
+// evict them from their in-process tier.
+const invalidations = "cache:invalidations"
+
+// clockKey counts invalidations. Invalidate stamps each tag with the
+// next count, and a fill is only cached if none of its tags was stamped
+// after the count read before it loaded, since it may predate the write.
+const clockKey = "cache:clock"
+
+// stampTTL is how long a tag's stamp is kept, bounding how long a fill
+// can run and still be checked against it. Fills are bounded by request
+// timeouts and the lock TTL, well under it.
+const stampTTL = 10 * time.Minute
+
+// stamp advances the clock and records it on the stamp keys in KEYS[2:].
+var stamp = redis.NewScript(`
+local now = redis.call("incr", KEYS[1])
+for i = 2, #KEYS do
+	redis.call("set", KEYS[i], now, "px", ARGV[1])
+end
+return now
+`)
+
+// setCurrent caches ARGV[2] under KEYS[1] for ARGV[3] ms unless a stamp
+// key is above the clock reading ARGV[1]. KEYS[1] is followed by n stamp
+// keys, then the n tag sets to record it in for ARGV[4] ms.
+var setCurrent = redis.NewScript(`
+local n = (#KEYS - 1) / 2
+for i = 2, n + 1 do
+	if tonumber(redis.call("get", KEYS[i]) or "0") > tonumber(ARGV[1]) then
+		return 0
+	end
+end
+redis.call("set", KEYS[1], ARGV[2], "px", ARGV[3])
+for i = n + 2, #KEYS do
+	redis.call("sadd", KEYS[i], KEYS[1])
+	redis.call("pexpire", KEYS[i], ARGV[4])
+end
+return 1
+`)
+
+// TTL is how long each class of entry is cached.
+type TTL struct {
+	Recipe time.Duration
+	List   time.Duration
+	Search time.Duration
+	// Stale is how long an expired or invalidated entry can still be
+	// served by Fetch while it is refreshed.
+	Stale time.Duration
+	// Local caps how long an entry is kept in process, bounding how
+	// stale a replica can be if it misses an invalidation.
+	Local time.Duration
+}
+
+// Entry is a value to cache along with the tags it is invalidated by.
+type Entry struct {
+	Key   string
+	Value []byte
+	Tags  []string
+}
+
+// Options configures a Cache.
+type Options struct {
+	TTL TTL
+	// LocalBytes bounds the entries held in process.
+	LocalBytes int
+	// Compress gzips the payloads built by Encode.
+	Compress bool
+	// FailureThreshold consecutive Redis failures open the circuit
+	// breaker, which then probes Redis every ProbeInterval.
+	FailureThreshold int
+	ProbeInterval    time.Duration
+}
+
+// Cache stores encoded responses in Redis, with an in-process LRU in front
+// of it. Every entry is recorded under its tags so a write can clear
+// exactly the entries it affects; invalidations are broadcast over Redis
+// pub/sub so every replica evicts them from its in-process tier.
+//
+// Entries are kept in Redis for TTL.Stale past their TTL. An entry is
+// fresh while more than TTL.Stale of its Redis TTL remains; Get only
+// returns fresh entries whereas Fetch also serves stale ones. Only fresh
+// entries are held in process.
+//
+// Redis is optional: after repeated failures a circuit breaker bypasses
+// it and the cache carries on with its in-process tier alone.
+type Cache struct {
+	client *redis.Client
+	TTL    TTL
+	local  *local
+	// compress is whether Encode gzips payloads.
+	compress bool
+	breaker  *breaker
+
+	fills singleflight.Group
+	// lockTTL bounds how long a replica may hold a fill lock.
+	lockTTL time.Duration
+
+	mu sync.Mutex
+	// longest is the longest TTL used so far; tag sets are kept at least
+	// that long so they never expire before the entries they track.
+	longest time.Duration
+}
+
+// New returns a cache over client.
+func New(client *redis.Client, options Options) *Cache {
+	return &Cache{
+		client:   client,
+		TTL:      options.TTL,
+		local:    newLocal(options.LocalBytes),
+		compress: options.Compress,
+		breaker:  newBreaker(options.FailureThreshold, options.ProbeInterval),
+		lockTTL:  5 * time.Second,
+	}
+}
+
+func tagKey(tag string) string {
+	return "cache:tag:" + tag
+}
+
+func stampKey(tag string) string {
+	return "cache:invalidated:" + tag
+}
+
+// Get returns the fresh value cached under key.
+func (cache *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, fresh, err := cache.lookup(ctx, key)
+	if err == nil && !fresh {
+		return nil, ErrMiss
+	}
+	return val, err
+}
+
+// lookup returns the value cached under key, fresh or stale.
+func (cache *Cache) lookup(ctx context.Context, key string) ([]byte, bool, error) {
+	values, fresh, err := cache.lookupMany(ctx, []string{key})
+	if err != nil {
+		return nil, false, err
+	}
+	if values[0] == nil {
+		return nil, false, ErrMiss
+	}
+	return values[0], fresh[0], nil
+}
+
+// lookupMany reads keys from the in-process tier, then the rest from
+// Redis in one round trip.
+func (cache *Cache) lookupMany(ctx context.Context, keys []string) ([][]byte, []bool, error) {
+	values := make([][]byte, len(keys))
+	fresh := make([]bool, len(keys))
+	remote := make([]int, 0, len(keys))
+	for i, key := range keys {
+		if val, ok := cache.local.get(key); ok {
+			record(tierLocal, resultHit)
+			values[i], fresh[i] = val, true
+		} else {
+			record(tierLocal, resultMiss)
+			remote = append(remote, i)
+		}
+	}
+	if len(remote) == 0 || !cache.Available() {
+		return values, fresh, nil
+	}
+	// go-redis v6 doesn't take a context, so calls are bounded by the
+	// client's read and write timeouts; a request already out of time
+	// doesn't make them
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	pipe := cache.client.Pipeline()
+	defer pipe.Close()
+	gets := make([]*redis.StringCmd, len(remote))
+	ttls := make([]*redis.DurationCmd, len(remote))
+	for j, i := range remote {
+		gets[j] = pipe.Get(keys[i])
+		ttls[j] = pipe.PTTL(keys[i])
+	}
+	if _, err := pipe.Exec(); cache.observe(err) != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+	for j, i := range remote {
+		val, err := gets[j].Bytes()
+		if err != nil {
+			record(tierRedis, resultMiss)
+			continue
+		}
+		values[i] = val
+		// a negative TTL means the key has no expiry
+		ttl := ttls[j].Val()
+		fresh[i] = ttl < 0 || ttl > cache.TTL.Stale
+		if !fresh[i] {
+			record(tierRedis, resultStale)
+			continue
+		}
+		record(tierRedis, resultHit)
+		if ttl < 0 {
+			ttl = cache.TTL.Local
+		} else {
+			ttl -= cache.TTL.Stale
+		}
+		cache.setLocal(keys[i], val, ttl)
+	}
+	return values, fresh, nil
+}
+
+func (cache *Cache) setLocal(key string, value []byte, ttl time.Duration) {
+	if ttl > cache.TTL.Local {
+		ttl = cache.TTL.Local
+	}
+	cache.local.set(key, value, ttl)
+}
+
+// GetMany returns the fresh values cached under keys in one round trip,
+// with a nil value for each miss.
+func (cache *Cache) GetMany(ctx context.Context, keys []string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return make([][]byte, 0), nil
+	}
+	values, fresh, err := cache.lookupMany(ctx, keys)
+	if err != nil {
+		return make([][]byte, len(keys)), err
+	}
+	for i := range values {
+		if !fresh[i] {
+			values[i] = nil
+		}
+	}
+	return values, nil
+}
+
+// Set caches value under key for ttl and records it under tags.
+func (cache *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	return cache.SetMany(ctx, []Entry{{Key: key, Value: value, Tags: tags}}, ttl)
+}
+
+// SetMany caches entries for ttl in one round trip.
+func (cache *Cache) SetMany(ctx context.Context, entries []Entry, ttl time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		cache.setLocal(entry.Key, entry.Value, ttl)
+	}
+	if !cache.Available() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	expiry := ttl + cache.TTL.Stale
+	tagTTL := cache.extend(expiry)
+	pipe := cache.client.Pipeline()
+	defer pipe.Close()
+	for _, entry := range entries {
+		pipe.Set(entry.Key, entry.Value, expiry)
+		for _, tag := range entry.Tags {
+			pipe.SAdd(tagKey(tag), entry.Key)
+			pipe.Expire(tagKey(tag), tagTTL)
+		}
+	}
+	_, err := pipe.Exec()
+	return cache.observe(err)
+}
+
+// Clock returns the invalidation count. Read it before loading values
+// from the database and pass it to SetManyIfCurrent, so values that an
+// invalidation overtook aren't cached. It is 0 while Redis is
+// unavailable, when SetManyIfCurrent only writes to the in-process tier.
+func (cache *Cache) Clock(ctx context.Context) (int64, error) {
+	if !cache.Available() {
+		return 0, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	now, err := cache.client.Get(clockKey).Int64()
+	if cache.observe(err) == redis.Nil {
+		return 0, nil
+	}
+	return now, err
+}
+
+// SetManyIfCurrent caches entries like SetMany, except those with a tag
+// invalidated after Clock returned since: they may have been loaded
+// before the write the invalidation is for.
+func (cache *Cache) SetManyIfCurrent(ctx context.Context, entries []Entry, ttl time.Duration, since int64) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	// set first so an invalidation from here on evicts them
+	for _, entry := range entries {
+		cache.setLocal(entry.Key, entry.Value, ttl)
+	}
+	if !cache.Available() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	expiry := ttl + cache.TTL.Stale
+	tagTTL := cache.extend(expiry)
+	pipe := cache.client.Pipeline()
+	defer pipe.Close()
+	stored := make([]*redis.Cmd, len(entries))
+	for i, entry := range entries {
+		keys := make([]string, 0, 1+2*len(entry.Tags))
+		keys = append(keys, entry.Key)
+		for _, tag := range entry.Tags {
+			keys = append(keys, stampKey(tag))
+		}
+		for _, tag := range entry.Tags {
+			keys = append(keys, tagKey(tag))
+		}
+		stored[i] = setCurrent.Eval(pipe, keys, since, entry.Value, milliseconds(expiry), milliseconds(tagTTL))
+	}
+	if _, err := pipe.Exec(); cache.observe(err) != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if n, _ := stored[i].Int64(); n == 0 {
+			cache.local.remove(entry.Key)
+		}
+	}
+	return nil
+}
+
+func milliseconds(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+func (cache *Cache) extend(ttl time.Duration) time.Duration {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if ttl > cache.longest {
+		cache.longest = ttl
+	}
+	return cache.longest
+}
+
+// Invalidate marks every entry recorded under any of tags as stale, so
+// Get no longer returns it and Fetch refreshes it. While Redis is
+// unavailable the invalidation is postponed until it is back.
+//
+// It takes no context: once a write is made its invalidation must not be
+// abandoned because the request that made it runs out of time.
+func (cache *Cache) Invalidate(tags ...string) error {
+	pendingTags, pendingKeys := cache.takePending()
+	tags = append(tags, pendingTags...)
+	if len(tags) == 0 && len(pendingKeys) == 0 {
+		return nil
+	}
+	if !cache.Available() {
+		cache.postpone(tags, pendingKeys)
+		return nil
+	}
+	if err := cache.observe(cache.invalidate(tags, pendingKeys)); err != nil {
+		cache.postpone(tags, pendingKeys)
+		return err
+	}
+	return nil
+}
+
+// invalidate marks the entries under tags as stale and deletes keys.
+func (cache *Cache) invalidate(tags []string, keys []string) error {
+	// stamp before reading the tag sets: a fill cached after this is
+	// refused, and one cached before it is in the sets
+	if len(tags) > 0 {
+		stamps := make([]string, 0, len(tags)+1)
+		stamps = append(stamps, clockKey)
+		for _, tag := range tags {
+			stamps = append(stamps, stampKey(tag))
+		}
+		if err := stamp.Run(cache.client, stamps, milliseconds(stampTTL)).Err(); err != nil {
+			return err
+		}
+	}
+
+	pipe := cache.client.Pipeline()
+	defer pipe.Close()
+	members := make([]*redis.StringSliceCmd, len(tags))
+	for i, tag := range tags {
+		members[i] = pipe.SMembers(tagKey(tag))
+	}
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		return err
+	}
+
+	stale := make([]string, 0)
+	for i, tag := range tags {
+		keys = append(keys, tagKey(tag))
+		stale = append(stale, members[i].Val()...)
+	}
+	if cache.TTL.Stale <= 0 {
+		keys = append(keys, stale...)
+	} else if len(stale) > 0 {
+		expire := cache.client.Pipeline()
+		defer expire.Close()
+		for _, key := range stale {
+			expire.PExpire(key, cache.TTL.Stale)
+		}
+		if _, err := expire.Exec(); err != nil && err != redis.Nil {
+			return err
+		}
+	}
+	cache.evict(append(stale, keys...))
+	return cache.client.Del(keys...).Err()
+}
+
+// Delete removes keys from the cache.
+func (cache *Cache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if !cache.Available() {
+		cache.local.remove(keys...)
+		cache.postpone(nil, keys)
+		return nil
+	}
+	cache.evict(keys)
+	if err := cache.observe(cache.client.Del(keys...).Err()); err != nil {
+		cache.postpone(nil, keys)
+		return err
+	}
+	return nil
+}
+
+// evict drops keys from this replica's in-process tier and tells the
+// other replicas to do the same.
+func (cache *Cache) evict(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	cache.local.remove(keys...)
+	data, _ := json.Marshal(keys)
+	if err := cache.observe(cache.client.Publish(invalidations, data).Err()); err != nil {
+		log.Error(err)
+	}
+}
+
+// Subscribe evicts the keys other replicas invalidate from the
+// in-process tier until ctx is done.
+func (cache *Cache) Subscribe(ctx context.Context) {
+	pubsub := cache.client.Subscribe(invalidations)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			keys := make([]string, 0)
+			if err := json.Unmarshal([]byte(msg.Payload), &keys); err != nil {
+				log.Error(err)
+				continue
+			}
+			cache.local.remove(keys...)
+		}
+	}
+}
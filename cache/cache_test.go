@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// testCache returns a cache over the Redis at REDIS_TEST_ADDR, skipping
+// the test when none is configured.
+func testCache(t *testing.T) *Cache {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping().Err(); err != nil {
+		t.Fatalf("redis at %s: %v", addr, err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return New(client, Options{
+		TTL:              TTL{Recipe: time.Minute, Local: time.Minute},
+		LocalBytes:       1 << 20,
+		FailureThreshold: 3,
+		ProbeInterval:    time.Second,
+	})
+}
+
+func TestSetManyIfCurrentSkipsInvalidated(t *testing.T) {
+	cache := testCache(t)
+	ctx := context.Background()
+	id := time.Now().UnixNano()
+	stale := Entry{
+		Key:   fmt.Sprintf("test:recipe:%d:stale", id),
+		Value: []byte("old"),
+		Tags:  []string{fmt.Sprintf("test:recipe:%d:stale", id)},
+	}
+	current := Entry{
+		Key:   fmt.Sprintf("test:recipe:%d:current", id),
+		Value: []byte("new"),
+		Tags:  []string{fmt.Sprintf("test:recipe:%d:current", id)},
+	}
+	t.Cleanup(func() { cache.Delete(stale.Key, current.Key) })
+
+	since, err := cache.Clock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a write lands between the read and the cache fill
+	if err := cache.Invalidate(stale.Tags...); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.SetManyIfCurrent(ctx, []Entry{stale, current}, cache.TTL.Recipe, since); err != nil {
+		t.Fatal(err)
+	}
+
+	if val, err := cache.Get(ctx, stale.Key); err != ErrMiss {
+		t.Errorf("Get(%s) = %q, %v; want ErrMiss", stale.Key, val, err)
+	}
+	if val, err := cache.Get(ctx, current.Key); err != nil || string(val) != "new" {
+		t.Errorf("Get(%s) = %q, %v; want %q", current.Key, val, err, "new")
+	}
+	// and the value isn't served from this replica's in-process tier either
+	if _, ok := cache.local.get(stale.Key); ok {
+		t.Errorf("%s kept in process", stale.Key)
+	}
+}
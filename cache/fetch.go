@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+// Fill loads the value for a key on a miss, along with the tags it is
+// invalidated by.
+type Fill func(ctx context.Context) ([]byte, []string, error)
+
+// pollInterval is how often a replica waiting on another's fill lock
+// checks whether the value has been cached.
+const pollInterval = 25 * time.Millisecond
+
+// release deletes a lock only if it is still held by the caller.
+var release = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+func lockKey(key string) string {
+	return "cache:lock:" + key
+}
+
+// Fetch returns the value cached under key, calling fill to load and
+// cache it on a miss. Concurrent misses for a key share one fill: within
+// a process through singleflight, and across replicas through a short
+// Redis lock whose losers wait for the winner's value. A stale value is
+// returned immediately while a single caller refreshes it in the
+// background.
+//
+// Fetch returns ctx's error as soon as ctx is done. A shared fill runs
+// until ctx's deadline but isn't cancelled with ctx, since other callers
+// may be waiting on it; background refreshes don't depend on ctx at all.
+func (cache *Cache) Fetch(ctx context.Context, key string, ttl time.Duration, fill Fill) ([]byte, error) {
+	val, fresh, err := cache.lookup(ctx, key)
+	switch {
+	case err == nil && fresh:
+		return val, nil
+	case err == nil:
+		go cache.fills.Do("refresh:"+key, func() (interface{}, error) {
+			cache.refresh(key, ttl, fill)
+			return nil, nil
+		})
+		return val, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case err != ErrMiss:
+		log.Error(err)
+	}
+
+	shared := cache.fills.DoChan(key, func() (interface{}, error) {
+		fillCtx, cancel := detach(ctx)
+		defer cancel()
+		return cache.fill(fillCtx, key, ttl, fill)
+	})
+	select {
+	case result := <-shared:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]byte), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detach returns a context with ctx's deadline but not its cancellation.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(context.Background(), deadline)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// fill loads key under the replica-wide lock, or waits for the replica
+// holding it. If the lock can't be taken or the holder doesn't finish in
+// time, the value is loaded without it.
+func (cache *Cache) fill(ctx context.Context, key string, ttl time.Duration, fill Fill) ([]byte, error) {
+	token, locked, err := cache.lock(ctx, key)
+	if err == nil && !locked {
+		deadline := time.Now().Add(cache.lockTTL)
+		for time.Now().Before(deadline) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(pollInterval):
+			}
+			if val, err := cache.Get(ctx, key); err == nil {
+				return val, nil
+			}
+			if token, locked, err = cache.lock(ctx, key); locked || err != nil {
+				break
+			}
+		}
+	}
+	if locked {
+		defer cache.unlock(key, token)
+	}
+	return cache.load(ctx, key, ttl, fill)
+}
+
+// refresh reloads a stale key unless another replica already is. It is
+// bounded by the lock's TTL rather than by any request.
+func (cache *Cache) refresh(key string, ttl time.Duration, fill Fill) {
+	ctx, cancel := context.WithTimeout(context.Background(), cache.lockTTL)
+	defer cancel()
+	token, locked, _ := cache.lock(ctx, key)
+	if !locked {
+		return
+	}
+	defer cache.unlock(key, token)
+	if _, err := cache.load(ctx, key, ttl, fill); err != nil {
+		log.Error(err)
+	}
+}
+
+// load calls fill and caches its value, unless one of its tags is
+// invalidated while it runs.
+func (cache *Cache) load(ctx context.Context, key string, ttl time.Duration, fill Fill) ([]byte, error) {
+	since, clockErr := cache.Clock(ctx)
+	val, tags, err := fill(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if clockErr != nil {
+		// without a reading there is nothing to check the fill against
+		log.Error(clockErr)
+		return val, nil
+	}
+	if err := cache.SetManyIfCurrent(ctx, []Entry{{Key: key, Value: val, Tags: tags}}, ttl, since); err != nil {
+		log.Error(err)
+	}
+	return val, nil
+}
+
+// lock takes the fill lock for key. An error means Redis is unreachable
+// and the caller should load without waiting.
+func (cache *Cache) lock(ctx context.Context, key string) (string, bool, error) {
+	if !cache.Available() {
+		return "", false, ErrUnavailable
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	if err := ctx.Err(); err != nil {
+		return "", false, err
+	}
+	ok, err := cache.client.SetNX(lockKey(key), token, cache.lockTTL).Result()
+	if cache.observe(err) != nil {
+		log.Error(err)
+		return token, false, err
+	}
+	return token, ok, nil
+}
+
+func (cache *Cache) unlock(key string, token string) {
+	if err := cache.observe(release.Run(cache.client, []string{lockKey(key)}, token).Err()); err != nil && err != redis.Nil {
+		log.Error(err)
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// local is a size-bounded LRU of fresh entries held in process in front
+// of Redis. Entries expire no later than they would go stale in Redis.
+type local struct {
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type localEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func newLocal(maxBytes int) *local {
+	return &local{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (l *local) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*localEntry)
+	if time.Now().After(entry.expires) {
+		l.removeElement(elem)
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (l *local) set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 || len(value) > l.maxBytes {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.items[key]; ok {
+		l.removeElement(elem)
+	}
+	l.items[key] = l.order.PushFront(&localEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	l.bytes += len(value)
+	for l.bytes > l.maxBytes {
+		l.removeElement(l.order.Back())
+	}
+}
+
+func (l *local) remove(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := l.items[key]; ok {
+			l.removeElement(elem)
+		}
+	}
+}
+
+func (l *local) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.items = make(map[string]*list.Element)
+	l.bytes = 0
+}
+
+func (l *local) removeElement(elem *list.Element) {
+	entry := l.order.Remove(elem).(*localEntry)
+	delete(l.items, entry.key)
+	l.bytes -= len(entry.value)
+}
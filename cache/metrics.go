@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// tiers and lookup results as reported in metrics
+const (
+	tierLocal = "local"
+	tierRedis = "redis"
+
+	resultHit   = "hit"
+	resultStale = "stale"
+	resultMiss  = "miss"
+)
+
+var lookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Number of cache lookups per tier and result",
+	},
+	[]string{"tier", "result"},
+)
+
+// available is 1 while Redis is in use and 0 while the breaker is open.
+var available = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "cache_redis_available",
+		Help: "Whether the cache is using Redis (1) or degraded to the in-process tier (0)",
+	},
+)
+
+var trips = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "cache_breaker_trips_total",
+		Help: "Number of times Redis was bypassed after repeated failures",
+	},
+)
+
+func init() {
+	available.Set(1)
+}
+
+// hits and misses per tier since start, for the hit ratio gauges
+var (
+	hits   = map[string]*uint64{tierLocal: new(uint64), tierRedis: new(uint64)}
+	misses = map[string]*uint64{tierLocal: new(uint64), tierRedis: new(uint64)}
+)
+
+func record(tier string, result string) {
+	lookups.WithLabelValues(tier, result).Inc()
+	if result == resultMiss {
+		atomic.AddUint64(misses[tier], 1)
+	} else {
+		atomic.AddUint64(hits[tier], 1)
+	}
+}
+
+// hitRatio reports the share of lookups a tier answered since start.
+// Lookups the local tier misses fall through to Redis.
+func hitRatio(tier string) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name:        "cache_hit_ratio",
+			Help:        "Share of lookups answered by each cache tier",
+			ConstLabels: prometheus.Labels{"tier": tier},
+		},
+		func() float64 {
+			hit := float64(atomic.LoadUint64(hits[tier]))
+			total := hit + float64(atomic.LoadUint64(misses[tier]))
+			if total == 0 {
+				return 0
+			}
+			return hit / total
+		},
+	)
+}
+
+// Collectors are the cache metrics to register with Prometheus.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{lookups, hitRatio(tierLocal), hitRatio(tierRedis), available, trips}
+}
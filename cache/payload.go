@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// gzipMagic opens every gzip stream; JSON can never start with it, so
+// compressed and plain payloads can share a cache.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// Encode marshals v to JSON as a cache payload, gzip-compressed when the
+// cache is configured to compress.
+func (cache *Cache) Encode(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || !cache.compress {
+		return data, err
+	}
+
+	var b bytes.Buffer
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+	zw.Reset(&b)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Compressed reports whether payload was gzip-compressed by Encode.
+func Compressed(payload []byte) bool {
+	return bytes.HasPrefix(payload, gzipMagic)
+}
+
+// Decode returns payload as plain JSON, decompressing it if needed.
+func Decode(payload []byte) ([]byte, error) {
+	if !Compressed(payload) {
+		return payload, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
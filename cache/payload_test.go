@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadRecipes(b *testing.B) []map[string]interface{} {
+	data, err := os.ReadFile("../recipes.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var recipes []map[string]interface{}
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		b.Fatal(err)
+	}
+	return recipes
+}
+
+func BenchmarkEncode(b *testing.B) {
+	recipes := loadRecipes(b)
+	for _, bc := range []struct {
+		name     string
+		compress bool
+	}{
+		{"plain", false},
+		{"gzip", true},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cache := New(nil, Options{Compress: bc.compress})
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := cache.Encode(recipes); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	payload, err := New(nil, Options{Compress: true}).Encode(loadRecipes(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
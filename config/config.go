@@ -0,0 +1,261 @@
+// Package config loads the API's settings from defaults, an optional
+// YAML or TOML file, environment variables and command-line flags, each
+// overriding the previous.
+//
+// Every setting is a leaf field below, tagged with its key in the file
+// (nested tables join with dots), its environment variable and a usage
+// line. Its flag is the key with dashes, e.g. -server.read-timeout.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	LogLevel   string     `key:"log_level" env:"LOG_LEVEL" usage:"Log level, e.g. debug, info or warn"`
+	MongoDB    MongoDB    `key:"mongodb"`
+	Redis      Redis      `key:"redis"`
+	Startup    Startup    `key:"startup"`
+	Cache      Cache      `key:"cache"`
+	Similarity Similarity `key:"similarity"`
+	Health     Health     `key:"health"`
+	Shutdown   Shutdown   `key:"shutdown"`
+	Server     Server     `key:"server"`
+	TLS        TLS        `key:"tls"`
+	Request    Request    `key:"request"`
+	Admin      Admin      `key:"admin"`
+	Auth       Auth       `key:"auth"`
+}
+
+type MongoDB struct {
+	Username string `key:"username" env:"MONGO_INITDB_ROOT_USERNAME" usage:"MongoDB user"`
+	Password string `key:"password" env:"MONGO_INITDB_ROOT_PASSWORD" usage:"MongoDB password" secret:"true"`
+	Hostname string `key:"hostname" env:"MONGODB_HOSTNAME" usage:"MongoDB host"`
+	Port     int    `key:"port" env:"MONGODB_PORT" usage:"MongoDB port"`
+	Database string `key:"database" env:"MONGODB_DATABASE" usage:"MongoDB database"`
+}
+
+type Redis struct {
+	Host     string `key:"host" env:"REDIS_HOST" usage:"Redis host"`
+	Port     int    `key:"port" env:"REDIS_PORT" usage:"Redis port"`
+	Password string `key:"password" env:"REDIS_PASSWORD" usage:"Redis password" secret:"true"`
+	// Redis is optional, so startup gives up on it sooner than on MongoDB
+	ConnectAttempts int `key:"connect_attempts" env:"REDIS_CONNECT_ATTEMPTS" usage:"Attempts to connect to Redis at startup, 0 for no limit"`
+	// go-redis doesn't take a context, so these bound each cache call
+	ReadTimeout  time.Duration `key:"read_timeout" env:"REDIS_READ_TIMEOUT" usage:"Timeout for reading Redis replies"`
+	WriteTimeout time.Duration `key:"write_timeout" env:"REDIS_WRITE_TIMEOUT" usage:"Timeout for writing Redis commands"`
+}
+
+type Startup struct {
+	Timeout        time.Duration `key:"timeout" env:"STARTUP_TIMEOUT" usage:"How long startup waits for the databases"`
+	RetryInitial   time.Duration `key:"retry_initial" env:"CONNECT_RETRY_INITIAL" usage:"Delay before retrying a failed connection, doubled after each retry"`
+	RetryMax       time.Duration `key:"retry_max" env:"CONNECT_RETRY_MAX" usage:"Longest delay between connection retries"`
+	AttemptTimeout time.Duration `key:"attempt_timeout" env:"CONNECT_ATTEMPT_TIMEOUT" usage:"Timeout of each connection attempt"`
+}
+
+type Cache struct {
+	RecipeTTL        time.Duration `key:"recipe_ttl" env:"CACHE_RECIPE_TTL" usage:"How long a recipe is cached"`
+	ListTTL          time.Duration `key:"list_ttl" env:"CACHE_LIST_TTL" usage:"How long a recipe list is cached"`
+	SearchTTL        time.Duration `key:"search_ttl" env:"CACHE_SEARCH_TTL" usage:"How long search results are cached"`
+	StaleWindow      time.Duration `key:"stale_window" env:"CACHE_STALE_WINDOW" usage:"How long expired entries are served while refreshed"`
+	LocalTTL         time.Duration `key:"local_ttl" env:"CACHE_LOCAL_TTL" usage:"How long entries are kept in process"`
+	LocalMaxMB       int           `key:"local_max_mb" env:"CACHE_LOCAL_MAX_MB" usage:"Size of the in-process cache in MB"`
+	Compress         bool          `key:"compress" env:"CACHE_COMPRESS" usage:"Gzip cached responses"`
+	FailureThreshold int           `key:"failure_threshold" env:"CACHE_FAILURE_THRESHOLD" usage:"Consecutive Redis failures before it is bypassed"`
+	ProbeInterval    time.Duration `key:"probe_interval" env:"CACHE_PROBE_INTERVAL" usage:"How often a bypassed Redis is probed"`
+	Warmup           bool          `key:"warmup" env:"CACHE_WARMUP" usage:"Warm up the cache at startup"`
+	WarmupTop        int           `key:"warmup_top" env:"CACHE_WARMUP_TOP" usage:"Number of top-rated recipes a warm-up caches"`
+}
+
+type Similarity struct {
+	RebuildInterval time.Duration `key:"rebuild_interval" env:"SIMILARITY_REBUILD_INTERVAL" usage:"How often the similarity index is rebuilt"`
+}
+
+type Health struct {
+	ReadinessTimeout time.Duration `key:"readiness_timeout" env:"READINESS_TIMEOUT" usage:"Timeout of each readiness check"`
+}
+
+type Shutdown struct {
+	Delay   time.Duration `key:"delay" env:"SHUTDOWN_DELAY" usage:"How long readiness fails before the server stops"`
+	Timeout time.Duration `key:"timeout" env:"SHUTDOWN_TIMEOUT" usage:"How long in-flight requests have to finish"`
+}
+
+type Server struct {
+	Addr              string        `key:"addr" env:"SERVER_ADDR" usage:"Address to listen on"`
+	Socket            string        `key:"socket" env:"SERVER_SOCKET" usage:"Unix socket to listen on instead of the address"`
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" usage:"Timeout for reading request headers"`
+	ReadTimeout       time.Duration `key:"read_timeout" env:"SERVER_READ_TIMEOUT" usage:"Timeout for reading requests"`
+	WriteTimeout      time.Duration `key:"write_timeout" env:"SERVER_WRITE_TIMEOUT" usage:"Timeout for writing responses"`
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" usage:"How long idle connections are kept open"`
+	MaxBodyMB         int           `key:"max_body_mb" env:"SERVER_MAX_BODY_MB" usage:"Largest request body in MB"`
+	HTTP2             bool          `key:"http2" env:"SERVER_HTTP2" usage:"Negotiate HTTP/2 over TLS"`
+	H2C               bool          `key:"h2c" env:"SERVER_H2C" usage:"Accept cleartext HTTP/2"`
+}
+
+type TLS struct {
+	CertFile       string        `key:"cert_file" env:"TLS_CERT_FILE" usage:"TLS certificate file"`
+	KeyFile        string        `key:"key_file" env:"TLS_KEY_FILE" usage:"TLS key file"`
+	ReloadInterval time.Duration `key:"reload_interval" env:"TLS_RELOAD_INTERVAL" usage:"How often the TLS files are checked for renewal"`
+}
+
+type Request struct {
+	Timeout time.Duration `key:"timeout" env:"REQUEST_TIMEOUT" usage:"Timeout of each request"`
+	// Routes overrides Timeout per route, keyed by method and path
+	Routes RouteTimeouts `key:"route_timeouts" env:"REQUEST_TIMEOUTS" usage:"Per-route timeouts as \"METHOD /path=duration\", comma-separated"`
+}
+
+type Admin struct {
+	Username string `key:"username" env:"ADMIN_USERNAME" usage:"Admin user, admin endpoints are disabled without one"`
+	Password string `key:"password" env:"ADMIN_PASSWORD" usage:"Admin password" secret:"true"`
+}
+
+type Auth struct {
+	UserSecret string `key:"user_secret" env:"USER_ID_SECRET" usage:"Secret the gateway signs X-User-ID with, user IDs are ignored without one" secret:"true"`
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() *Config {
+	return &Config{
+		LogLevel: "info",
+		MongoDB: MongoDB{
+			Hostname: "localhost",
+			Port:     27017,
+			Database: "recipe",
+		},
+		Redis: Redis{
+			Host:            "localhost",
+			Port:            6379,
+			ConnectAttempts: 3,
+			ReadTimeout:     time.Second,
+			WriteTimeout:    time.Second,
+		},
+		Startup: Startup{
+			Timeout:        2 * time.Minute,
+			RetryInitial:   500 * time.Millisecond,
+			RetryMax:       10 * time.Second,
+			AttemptTimeout: 5 * time.Second,
+		},
+		Cache: Cache{
+			RecipeTTL:        10 * time.Minute,
+			ListTTL:          5 * time.Minute,
+			SearchTTL:        5 * time.Minute,
+			StaleWindow:      30 * time.Second,
+			LocalTTL:         30 * time.Second,
+			LocalMaxMB:       64,
+			Compress:         true,
+			FailureThreshold: 5,
+			ProbeInterval:    5 * time.Second,
+			WarmupTop:        100,
+		},
+		Similarity: Similarity{
+			RebuildInterval: 15 * time.Minute,
+		},
+		Health: Health{
+			ReadinessTimeout: 2 * time.Second,
+		},
+		Shutdown: Shutdown{
+			Delay:   5 * time.Second,
+			Timeout: 30 * time.Second,
+		},
+		Server: Server{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxBodyMB:         32,
+			HTTP2:             true,
+		},
+		TLS: TLS{
+			ReloadInterval: time.Minute,
+		},
+		Request: Request{
+			Timeout: 10 * time.Second,
+			// the bulk routes get longer, and a write deadline to match
+			// in place of the server's write timeout
+			Routes: RouteTimeouts{
+				"GET /api/v1/recipes/export":      50 * time.Second,
+				"POST /api/v1/recipes/import":     50 * time.Second,
+				"POST /api/v1/recipes/batch":      30 * time.Second,
+				"POST /api/v1/admin/cache/warmup": 50 * time.Second,
+			},
+		},
+	}
+}
+
+// Validate checks the settings, returning every problem found.
+func (config *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, err := log.ParseLevel(config.LogLevel)
+	check(err == nil, "log_level: unknown level %q", config.LogLevel)
+
+	check(config.MongoDB.Hostname != "", "mongodb.hostname: must be set")
+	check(validPort(config.MongoDB.Port), "mongodb.port: must be between 1 and 65535")
+	check(config.MongoDB.Database != "", "mongodb.database: must be set")
+	check(config.Redis.Host != "", "redis.host: must be set")
+	check(validPort(config.Redis.Port), "redis.port: must be between 1 and 65535")
+	check(config.Redis.ConnectAttempts >= 0, "redis.connect_attempts: must not be negative")
+
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"redis.read_timeout", config.Redis.ReadTimeout},
+		{"redis.write_timeout", config.Redis.WriteTimeout},
+		{"startup.timeout", config.Startup.Timeout},
+		{"startup.retry_initial", config.Startup.RetryInitial},
+		{"startup.retry_max", config.Startup.RetryMax},
+		{"startup.attempt_timeout", config.Startup.AttemptTimeout},
+		{"cache.recipe_ttl", config.Cache.RecipeTTL},
+		{"cache.list_ttl", config.Cache.ListTTL},
+		{"cache.search_ttl", config.Cache.SearchTTL},
+		{"cache.local_ttl", config.Cache.LocalTTL},
+		{"cache.probe_interval", config.Cache.ProbeInterval},
+		{"similarity.rebuild_interval", config.Similarity.RebuildInterval},
+		{"health.readiness_timeout", config.Health.ReadinessTimeout},
+		{"shutdown.timeout", config.Shutdown.Timeout},
+		{"server.read_header_timeout", config.Server.ReadHeaderTimeout},
+		{"server.read_timeout", config.Server.ReadTimeout},
+		{"server.write_timeout", config.Server.WriteTimeout},
+		{"server.idle_timeout", config.Server.IdleTimeout},
+	} {
+		check(d.value > 0, "%s: must be positive", d.key)
+	}
+	// a zero stale window, delay, reload interval or request timeout turns
+	// the feature off
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"cache.stale_window", config.Cache.StaleWindow},
+		{"shutdown.delay", config.Shutdown.Delay},
+		{"tls.reload_interval", config.TLS.ReloadInterval},
+		{"request.timeout", config.Request.Timeout},
+	} {
+		check(d.value >= 0, "%s: must not be negative", d.key)
+	}
+	check(config.Startup.RetryMax >= config.Startup.RetryInitial, "startup.retry_max: must not be below startup.retry_initial")
+
+	check(config.Cache.LocalMaxMB >= 0, "cache.local_max_mb: must not be negative")
+	check(config.Cache.FailureThreshold > 0, "cache.failure_threshold: must be positive")
+	check(config.Cache.WarmupTop >= 0, "cache.warmup_top: must not be negative")
+	check(config.Server.Addr != "" || config.Server.Socket != "", "server.addr: must be set unless server.socket is")
+	check(config.Server.MaxBodyMB > 0, "server.max_body_mb: must be positive")
+	check((config.TLS.CertFile == "") == (config.TLS.KeyFile == ""), "tls: cert_file and key_file must be set together")
+	check(config.Admin.Username == "" || config.Admin.Password != "", "admin.password: must be set with admin.username")
+	return errors.Join(errs...)
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in Redacted.
+const redacted = "******"
+
+// RouteTimeouts maps "METHOD /path" routes, with the path as registered,
+// to their request timeout.
+type RouteTimeouts map[string]time.Duration
+
+// String formats the timeouts the way they are read from the
+// environment, e.g. "GET /api/v1/recipes/export=50s".
+func (timeouts RouteTimeouts) String() string {
+	entries := make([]string, 0, len(timeouts))
+	for route, timeout := range timeouts {
+		entries = append(entries, route+"="+timeout.String())
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+func parseRouteTimeouts(value string) (RouteTimeouts, error) {
+	timeouts := make(RouteTimeouts)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not METHOD /path=duration", entry)
+		}
+		if err := timeouts.add(entry[:i], entry[i+1:]); err != nil {
+			return nil, err
+		}
+	}
+	return timeouts, nil
+}
+
+func (timeouts RouteTimeouts) add(route string, value string) error {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return fmt.Errorf("invalid timeout %q for %q", value, route)
+	}
+	timeouts[strings.Join(strings.Fields(route), " ")] = timeout
+	return nil
+}
+
+// setting is a leaf field of Config.
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// flag returns the command-line flag of the setting.
+func (s setting) flag() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+// settings lists the leaf fields of config in declaration order.
+func (config *Config) settings() []setting {
+	var settings []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			key := prefix + field.Tag.Get("key")
+			if field.Type.Kind() == reflect.Struct {
+				walk(key+".", v.Field(i))
+				continue
+			}
+			settings = append(settings, setting{
+				key:    key,
+				env:    field.Tag.Get("env"),
+				usage:  field.Tag.Get("usage"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(config).Elem())
+	return settings
+}
+
+// set parses value into the setting. Values come as strings from the
+// environment and flags, and as decoded YAML or TOML from files.
+func (s setting) set(value interface{}) error {
+	switch s.value.Interface().(type) {
+	case time.Duration:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%v is not a duration such as \"5s\"", value)
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(d))
+	case RouteTimeouts:
+		var timeouts RouteTimeouts
+		switch value := value.(type) {
+		case string:
+			var err error
+			if timeouts, err = parseRouteTimeouts(value); err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			timeouts = make(RouteTimeouts)
+			for route, timeout := range value {
+				if err := timeouts.add(route, fmt.Sprint(timeout)); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%v is not a table of route timeouts", value)
+		}
+		s.value.Set(reflect.ValueOf(timeouts))
+	case string:
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return fmt.Errorf("%v is not a string", value)
+		}
+		s.value.SetString(fmt.Sprint(value))
+	case int:
+		switch value := value.(type) {
+		case int:
+			s.value.SetInt(int64(value))
+		case int64:
+			s.value.SetInt(value)
+		case string:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%q is not an integer", value)
+			}
+			s.value.SetInt(int64(n))
+		default:
+			return fmt.Errorf("%v is not an integer", value)
+		}
+	case bool:
+		switch value := value.(type) {
+		case bool:
+			s.value.SetBool(value)
+		case string:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%q is not a boolean", value)
+			}
+			s.value.SetBool(b)
+		default:
+			return fmt.Errorf("%v is not a boolean", value)
+		}
+	}
+	return nil
+}
+
+// Load returns the defaults overridden by the file given with -config or
+// CONFIG_FILE, then by environment variables, then by flags in args, and
+// validates the result.
+func Load(name string, args []string) (*Config, error) {
+	config := Default()
+	settings := config.settings()
+
+	// flags are parsed first to find the file, but applied last
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config `file` (env CONFIG_FILE)")
+	values := make(map[string]string)
+	for _, s := range settings {
+		flags.Var(&flagValue{
+			name:   s.flag(),
+			values: values,
+			isBool: s.value.Kind() == reflect.Bool,
+		}, s.flag(), fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file != "" {
+		if err := config.loadFile(*file, settings); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range settings {
+		// empty variables are treated as unset, as compose files pass
+		// them on for every name in the env file
+		if value := os.Getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := values[s.flag()]; ok {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("-%s: %w", s.flag(), err)
+			}
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// loadFile applies a YAML or TOML file, chosen by its extension. Keys
+// that match no setting are an error, so typos don't go unnoticed.
+func (config *Config) loadFile(path string, settings []setting) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]interface{})
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("%s: config file must be .yaml, .yml or .toml", path)
+	}
+	// an empty YAML file decodes to io.EOF with some inputs
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+	var apply func(prefix string, values map[string]interface{}) error
+	apply = func(prefix string, values map[string]interface{}) error {
+		for name, value := range values {
+			key := prefix + name
+			if s, ok := byKey[key]; ok {
+				if err := s.set(value); err != nil {
+					return fmt.Errorf("%s: %s: %w", path, key, err)
+				}
+				continue
+			}
+			table, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: unknown setting %s", path, key)
+			}
+			if err := apply(key+".", table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return apply("", values)
+}
+
+// flagValue records a flag's value to apply once the file and
+// environment are loaded.
+type flagValue struct {
+	name   string
+	values map[string]string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	return ""
+}
+
+func (v *flagValue) Set(value string) error {
+	v.values[v.name] = value
+	return nil
+}
+
+// IsBoolFlag lets boolean flags be given without a value.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// Redacted returns every setting by key, with secrets hidden, for
+// logging the effective configuration.
+func (config *Config) Redacted() map[string]interface{} {
+	dump := make(map[string]interface{})
+	for _, s := range config.settings() {
+		value := s.value.Interface()
+		switch v := value.(type) {
+		case time.Duration, RouteTimeouts:
+			value = fmt.Sprint(v)
+		}
+		if s.secret && s.value.String() != "" {
+			value = redacted
+		}
+		dump[s.key] = value
+	}
+	return dump
+}
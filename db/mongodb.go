@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -23,22 +24,33 @@ var (
 	}
 )
 
-// Connects to a running MongoDB instance
-func ConnectToMongoDB(ctx context.Context, user, password, host, database, port string) (*MongoDB, error) {
-	client, err := mongo.NewClient(
-		options.Client().ApplyURI(uri(user, password, host, database, port)),
-	)
-	if err != nil {
-		log.Fatalf("Failed to create MongoDB client, err = %s", err)
-	}
-	// attempt to connect to db
-	err = connect(ctx, client)
-	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB server, err = %s", err)
+// Connects to a running MongoDB instance, retrying as configured until it
+// answers or ctx is done.
+func ConnectToMongoDB(ctx context.Context, retry Retry, user, password, host, database, port string) (*MongoDB, error) {
+	clientOptions := options.Client().ApplyURI(uri(user, password, host, database, port))
+	if err := clientOptions.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to create MongoDB client, err = %w", err)
 	}
-	err = mPing(ctx, client)
+
+	var client *mongo.Client
+	err := retry.do(ctx, "MongoDB", func(ctx context.Context) error {
+		// a client can only be connected once, so each attempt gets its own
+		c, err := mongo.NewClient(clientOptions)
+		if err != nil {
+			return fmt.Errorf("failed to create MongoDB client, err = %w", err)
+		}
+		if err := connect(ctx, c); err != nil {
+			return fmt.Errorf("failed to connect to MongoDB server, err = %w", err)
+		}
+		if err := mPing(ctx, c); err != nil {
+			c.Disconnect(context.Background())
+			return fmt.Errorf("failed to ping MongoDB server, err = %w", err)
+		}
+		client = c
+		return nil
+	})
 	if err != nil {
-		log.Fatalf("Failed to ping MongoDB server, err = %s", err)
+		return nil, err
 	}
 	log.Println("Connected to MongoDB!")
 	return &MongoDB{
@@ -47,7 +59,31 @@ func ConnectToMongoDB(ctx context.Context, user, password, host, database, port
 	}, nil
 }
 
+// Ping checks that the MongoDB server is reachable.
+func (db *MongoDB) Ping(ctx context.Context) error {
+	return mPing(ctx, db.Client)
+}
+
+// Close disconnects from MongoDB, waiting for in-use connections until
+// ctx is done.
+func (db *MongoDB) Close(ctx context.Context) error {
+	return db.Client.Disconnect(ctx)
+}
+
+// Version returns the version of the MongoDB server.
+func (db *MongoDB) Version(ctx context.Context) (string, error) {
+	var info struct {
+		Version string `bson:"version"`
+	}
+	err := db.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info)
+	return info.Version, err
+}
+
 func uri(user, password, host, database, port string) string {
+	if user == "" {
+		const format = "mongodb://%s:%s/%s"
+		return fmt.Sprintf(format, host, port, database)
+	}
 	const format = "mongodb://%s:%s@%s:%s/%s?authSource=admin"
 	return fmt.Sprintf(format, user, password, host, port, database)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis"
 )
@@ -18,20 +20,59 @@ var (
 	}
 )
 
-func ConnectToRedis(ctx context.Context, password, host, port string) (*RedisCache, error) {
+// Connects to a running Redis instance, retrying as configured. The
+// client is returned even if Redis can't be reached, as it reconnects
+// once Redis is up. readTimeout and writeTimeout bound every call, as
+// go-redis doesn't take a context.
+func ConnectToRedis(ctx context.Context, retry Retry, password, host, port string, readTimeout, writeTimeout time.Duration) (*RedisCache, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     address(host, port),
-		Password: password,
-		DB:       0,
+		Addr:         address(host, port),
+		Password:     password,
+		DB:           0,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
 	})
-	// attempt to ping db
-	if status := rPing(client); status.Val() != "PONG" {
-		log.Fatalf("Failed to ping Redis cache, status = %s", status.Val())
+	cache := &RedisCache{
+		Client: client,
+	}
+	// attempt to ping db; each attempt is bounded by the client's dial and
+	// read timeouts, as go-redis doesn't take a context
+	err := retry.do(ctx, "Redis", func(context.Context) error {
+		status := rPing(client)
+		if status.Val() != "PONG" {
+			return fmt.Errorf("failed to ping Redis cache, err = %v", status.Err())
+		}
+		return nil
+	})
+	if err != nil {
+		return cache, err
 	}
 	log.Println("Connected to Redis!")
-	return &RedisCache{
-		Client: client,
-	}, nil
+	return cache, nil
+}
+
+// Ping checks that the Redis server is reachable.
+func (cache *RedisCache) Ping() error {
+	return rPing(cache.Client).Err()
+}
+
+// Close closes the connections to Redis.
+func (cache *RedisCache) Close() error {
+	return cache.Client.Close()
+}
+
+// Version returns the version of the Redis server.
+func (cache *RedisCache) Version() (string, error) {
+	info, err := cache.Client.Info("server").Result()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(info, "\r\n") {
+		if version, ok := strings.CutPrefix(line, "redis_version:"); ok {
+			return version, nil
+		}
+	}
+	return "", nil
 }
 
 func address(host, port string) string {
@@ -0,0 +1,76 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// after is time.After, replaced in tests to skip the waits.
+var after = time.After
+
+// Retry configures how connecting to a database is retried at startup,
+// e.g. while its container is still starting.
+type Retry struct {
+	// Attempts caps the number of attempts; 0 retries until the context
+	// passed to the connect function is done.
+	Attempts int
+	// Initial is the delay before the first retry. It doubles after each
+	// failed attempt, up to Max, and is jittered so replicas started
+	// together don't retry in lockstep.
+	Initial time.Duration
+	Max     time.Duration
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+}
+
+// do calls attempt until it succeeds, the attempts run out or ctx is
+// done, and returns the last error.
+func (retry Retry) do(ctx context.Context, name string, attempt func(ctx context.Context) error) error {
+	delay := retry.Initial
+	if delay <= 0 {
+		delay = time.Second
+	}
+	if retry.Max < delay {
+		retry.Max = delay
+	}
+	for n := 1; ; n++ {
+		err := retry.attempt(ctx, attempt)
+		if err == nil {
+			return nil
+		}
+		if (retry.Attempts > 0 && n >= retry.Attempts) || ctx.Err() != nil {
+			return fmt.Errorf("%s: giving up after %d attempts: %w", name, n, err)
+		}
+
+		wait := jitter(delay)
+		log.Printf("%s: attempt %d failed, retrying in %s, err = %s", name, n, wait.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: giving up after %d attempts: %w", name, n, err)
+		case <-after(wait):
+		}
+		if delay *= 2; delay > retry.Max {
+			delay = retry.Max
+		}
+	}
+}
+
+func (retry Retry) attempt(ctx context.Context, attempt func(ctx context.Context) error) error {
+	if retry.Timeout <= 0 {
+		return attempt(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, retry.Timeout)
+	defer cancel()
+	return attempt(ctx)
+}
+
+// jitter returns a random delay between half of d and d.
+func jitter(d time.Duration) time.Duration {
+	if d < 2 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
@@ -0,0 +1,240 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeWaits replaces the waits between attempts with ones that return
+// at once, recording their durations.
+func fakeWaits(t *testing.T) *[]time.Duration {
+	waits := make([]time.Duration, 0)
+	original := after
+	after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	t.Cleanup(func() { after = original })
+	return &waits
+}
+
+// fakeMongoDB makes connecting succeed and the ping fail failures times,
+// recording the context of every attempt.
+func fakeMongoDB(t *testing.T, failures int) *[]context.Context {
+	attempts := make([]context.Context, 0)
+	originalConnect, originalPing := connect, mPing
+	connect = func(ctx context.Context, client *mongo.Client) error {
+		return nil
+	}
+	mPing = func(ctx context.Context, client *mongo.Client) error {
+		attempts = append(attempts, ctx)
+		if len(attempts) <= failures {
+			return errors.New("server selection error")
+		}
+		return nil
+	}
+	t.Cleanup(func() { connect, mPing = originalConnect, originalPing })
+	return &attempts
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    Retry
+		failures int
+		// delays are the waits before jitter
+		delays []time.Duration
+	}{
+		{
+			name:     "doubles up to max",
+			retry:    Retry{Initial: 10 * time.Millisecond, Max: 40 * time.Millisecond},
+			failures: 5,
+			delays:   []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond},
+		},
+		{
+			name:     "max below initial",
+			retry:    Retry{Initial: 20 * time.Millisecond, Max: 5 * time.Millisecond},
+			failures: 3,
+			delays:   []time.Duration{20 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:     "initial defaults to a second",
+			retry:    Retry{Max: 3 * time.Second},
+			failures: 3,
+			delays:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:     "first attempt succeeds",
+			retry:    Retry{Initial: 10 * time.Millisecond},
+			failures: 0,
+			delays:   []time.Duration{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			waits := fakeWaits(t)
+			attempts := fakeMongoDB(t, test.failures)
+
+			db, err := ConnectToMongoDB(context.Background(), test.retry, "", "", "localhost", "recipes", "27017")
+			if err != nil {
+				t.Fatalf("ConnectToMongoDB() error = %v", err)
+			}
+			defer db.Close(context.Background())
+			if len(*attempts) != test.failures+1 {
+				t.Errorf("attempts = %d, want %d", len(*attempts), test.failures+1)
+			}
+			if len(*waits) != len(test.delays) {
+				t.Fatalf("waits = %v, want %d", *waits, len(test.delays))
+			}
+			for i, wait := range *waits {
+				if wait < test.delays[i]/2 || wait > test.delays[i] {
+					t.Errorf("wait %d = %s, want between %s and %s", i, wait, test.delays[i]/2, test.delays[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     int
+		wantErr  string
+	}{
+		{"after the attempts run out", 3, 3, "MongoDB: giving up after 3 attempts: failed to ping MongoDB server"},
+		{"single attempt", 1, 1, "MongoDB: giving up after 1 attempts"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeWaits(t)
+			attempts := fakeMongoDB(t, 10)
+
+			retry := Retry{Attempts: test.attempts, Initial: time.Millisecond}
+			_, err := ConnectToMongoDB(context.Background(), retry, "", "", "localhost", "recipes", "27017")
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("ConnectToMongoDB() error = %v, want %q", err, test.wantErr)
+			}
+			if len(*attempts) != test.want {
+				t.Errorf("attempts = %d, want %d", len(*attempts), test.want)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	// waits never end, so only ctx can stop the retries
+	original := after
+	after = func(time.Duration) <-chan time.Time {
+		return make(chan time.Time)
+	}
+	t.Cleanup(func() { after = original })
+	fakeMongoDB(t, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := ConnectToMongoDB(ctx, Retry{Initial: time.Hour}, "", "", "localhost", "recipes", "27017")
+	if err == nil || !strings.Contains(err.Error(), "giving up after 1 attempts") {
+		t.Fatalf("ConnectToMongoDB() error = %v, want giving up after 1 attempts", err)
+	}
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"bounded", 50 * time.Millisecond},
+		{"unbounded", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeWaits(t)
+			attempts := fakeMongoDB(t, 2)
+
+			start := time.Now()
+			retry := Retry{Initial: time.Millisecond, Timeout: test.timeout}
+			db, err := ConnectToMongoDB(context.Background(), retry, "", "", "localhost", "recipes", "27017")
+			if err != nil {
+				t.Fatalf("ConnectToMongoDB() error = %v", err)
+			}
+			defer db.Close(context.Background())
+			for i, ctx := range *attempts {
+				deadline, ok := ctx.Deadline()
+				if test.timeout == 0 {
+					if ok {
+						t.Errorf("attempt %d has a deadline", i)
+					}
+					continue
+				}
+				if !ok || deadline.Before(start) || deadline.After(time.Now().Add(test.timeout)) {
+					t.Errorf("attempt %d deadline = %v, want within %s", i, deadline, test.timeout)
+				}
+				// each attempt's context ends with the attempt
+				if ctx.Err() == nil {
+					t.Errorf("attempt %d context not cancelled", i)
+				}
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []time.Duration{0, 1, 2, 3, time.Millisecond, time.Second, time.Minute}
+	for _, d := range tests {
+		for i := 0; i < 100; i++ {
+			got := jitter(d)
+			if d < 2 && got != d {
+				t.Fatalf("jitter(%s) = %s, want %s", d, got, d)
+			}
+			if got < d/2 || got > d {
+				t.Fatalf("jitter(%s) = %s, want between %s and %s", d, got, d/2, d)
+			}
+		}
+	}
+}
+
+func TestConnectToRedisRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		failures int
+		wantErr  bool
+	}{
+		{"answers within the attempts", 3, 2, false},
+		{"attempts run out", 2, 2, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeWaits(t)
+			pings := 0
+			original := rPing
+			rPing = func(client *redis.Client) *redis.StatusCmd {
+				pings++
+				if pings <= test.failures {
+					return redis.NewStatusResult("", errors.New("connection refused"))
+				}
+				return redis.NewStatusResult("PONG", nil)
+			}
+			t.Cleanup(func() { rPing = original })
+
+			retry := Retry{Attempts: test.attempts, Initial: time.Millisecond}
+			cache, err := ConnectToRedis(context.Background(), retry, "", "localhost", "6379", time.Second, time.Second)
+			defer cache.Close()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ConnectToRedis() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if cache == nil || cache.Client == nil {
+				t.Fatal("ConnectToRedis() returned no client")
+			}
+		})
+	}
+}
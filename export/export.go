@@ -0,0 +1,44 @@
+package export
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+	"github.com/wtlow003/recipe-gin-api/schemaorg"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var funcs = map[string]interface{}{
+	"join":        strings.Join,
+	"ingredients": schemaorg.Ingredients,
+	"steps":       schemaorg.Steps,
+	"inc":         func(i int) int { return i + 1 },
+}
+
+var (
+	markdownTemplate = texttemplate.Must(
+		texttemplate.New("recipe.md.tmpl").Funcs(funcs).ParseFS(templates, "templates/recipe.md.tmpl"),
+	)
+	htmlTemplate = htmltemplate.Must(
+		htmltemplate.New("recipe.html.tmpl").Funcs(funcs).ParseFS(templates, "templates/recipe.html.tmpl"),
+	)
+)
+
+// Markdown renders a recipe for the docs site.
+func Markdown(w io.Writer, recipe models.Recipe) error {
+	return markdownTemplate.Execute(w, recipe)
+}
+
+// HTML renders a print-friendly page with the recipe's JSON-LD embedded.
+func HTML(w io.Writer, recipe models.Recipe) error {
+	return htmlTemplate.Execute(w, struct {
+		Recipe models.Recipe
+		JSONLD schemaorg.Recipe
+	}{recipe, schemaorg.FromRecipe(recipe)})
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// maxBatchSize caps the operations accepted by BatchRecipes.
+const maxBatchSize = 1000
+
+const (
+	batchOK       = "ok"
+	batchNotFound = "not_found"
+	batchFailed   = "failed"
+	batchSkipped  = "skipped"
+)
+
+// BatchRecipes	godoc
+// @Summary		Batch write recipes
+// @Description	create, update and delete recipes in one bulk write. Ordered batches stop at the first operation that fails or targets a missing recipe and report the rest as skipped; unordered batches attempt every operation.
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		X-User-ID	header	string	false	"Owner of created recipes"
+// @Param		X-User-Signature	header	string	false	"Hex HMAC-SHA256 of X-User-ID, signed by the gateway"
+// @Param		batch		body	models.BatchRequest	true	"Operations"
+// @Success		200 {object}	models.BatchResponse
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/batch	[post]
+func (handler *RecipesHandler) BatchRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	var request models.BatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+	if len(request.Operations) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      fmt.Sprintf("A batch may contain at most %d operations.", maxBatchSize),
+		})
+		return
+	}
+	ordered := request.Ordered == nil || *request.Ordered
+
+	operations := request.Operations
+	results := make([]models.BatchResult, len(operations))
+	targets := make([]primitive.ObjectID, len(operations))
+	for i, op := range operations {
+		results[i] = models.BatchResult{Index: i, Op: op.Op, ID: op.ID}
+		id, err := validateOperation(op)
+		if err != nil {
+			results[i].Status = batchFailed
+			results[i].Error = err.Error()
+		}
+		targets[i] = id
+	}
+
+	existing, err := handler.existingRecipes(ctx, targets)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	// writes[k] carries operations[positions[k]]
+	writes := make([]mongo.WriteModel, 0, len(operations))
+	positions := make([]int, 0, len(operations))
+	owner := c.GetHeader(userHeader)
+	stopped := false
+	for i, op := range operations {
+		if stopped {
+			results[i].Status = batchSkipped
+			results[i].Error = ""
+			continue
+		}
+		if results[i].Status == batchFailed {
+			stopped = ordered
+			continue
+		}
+
+		var write mongo.WriteModel
+		switch op.Op {
+		case "create":
+			recipe := recipeFromUserDefined(*op.Recipe)
+			recipe.ID = primitive.NewObjectID()
+			recipe.PublishedAt = time.Now()
+			handler.classify(&recipe)
+			recipe.Owner = owner
+			results[i].ID = recipe.ID.Hex()
+			write = mongo.NewInsertOneModel().SetDocument(recipe)
+		case "update":
+			if !existing[targets[i]] {
+				results[i].Status = batchNotFound
+				stopped = ordered
+				continue
+			}
+			recipe := recipeFromUserDefined(*op.Recipe)
+			handler.classify(&recipe)
+			write = mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": targets[i]}).
+				SetUpdate(recipeUpdate(recipe))
+		case "delete":
+			if !existing[targets[i]] {
+				results[i].Status = batchNotFound
+				stopped = ordered
+				continue
+			}
+			// later operations in the batch no longer see the recipe
+			delete(existing, targets[i])
+			write = mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": targets[i]})
+		}
+		results[i].Status = batchOK
+		writes = append(writes, write)
+		positions = append(positions, i)
+	}
+
+	if len(writes) > 0 {
+		_, err := handler.Collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(ordered))
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+			for _, writeErr := range bulkErr.WriteErrors {
+				i := positions[writeErr.Index]
+				results[i].Status = batchFailed
+				results[i].Error = writeErr.Message
+			}
+			if ordered {
+				// the server stops at the first write error
+				for i := positions[bulkErr.WriteErrors[0].Index] + 1; i < len(results); i++ {
+					results[i].Status = batchSkipped
+					results[i].Error = ""
+				}
+			}
+		} else if err != nil {
+			serverError(c, err)
+			return
+		}
+	}
+
+	response := models.BatchResponse{Ordered: ordered, Results: results}
+	ids := make([]string, 0)
+	tags := make([]string, 0)
+	for i, result := range results {
+		if result.Status == batchOK && operations[i].Recipe != nil {
+			tags = append(tags, operations[i].Recipe.Tags...)
+		}
+		switch {
+		case result.Status == batchFailed:
+			response.Failed++
+		case result.Status != batchOK:
+		case result.Op == "create":
+			response.Created++
+		case result.Op == "update":
+			response.Updated++
+			ids = append(ids, targets[i].Hex())
+		case result.Op == "delete":
+			response.Deleted++
+			ids = append(ids, targets[i].Hex())
+		}
+	}
+
+	// invalidate once for the whole batch rather than per operation
+	if response.Created+response.Updated+response.Deleted > 0 {
+		invalidateRecipes(handler.cache, ids, tags)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// validateOperation checks an operation before anything is written and
+// returns the recipe ID it targets.
+func validateOperation(op models.BatchOperation) (primitive.ObjectID, error) {
+	var id primitive.ObjectID
+	if op.Op != "create" {
+		var err error
+		if id, err = primitive.ObjectIDFromHex(op.ID); err != nil {
+			return id, fmt.Errorf("invalid recipe ID %q", op.ID)
+		}
+	}
+	if op.Op == "delete" {
+		return id, nil
+	}
+	if op.Recipe == nil {
+		return id, errors.New("recipe is required")
+	}
+	return id, recipeFromUserDefined(*op.Recipe).Validate()
+}
+
+// existingRecipes reports which of ids are stored, in a single query.
+func (handler *RecipesHandler) existingRecipes(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	lookup := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !id.IsZero() {
+			lookup = append(lookup, id)
+		}
+	}
+	existing := make(map[primitive.ObjectID]bool)
+	if len(lookup) == 0 {
+		return existing, nil
+	}
+
+	cursor, err := handler.Collection.Find(ctx,
+		bson.M{"_id": bson.M{"$in": lookup}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			return nil, err
+		}
+		existing[recipe.ID] = true
+	}
+	return existing, cursor.Err()
+}
+
+func recipeFromUserDefined(recipe models.UserDefinedRecipe) models.Recipe {
+	return models.Recipe{
+		Name:         recipe.Name,
+		Tags:         recipe.Tags,
+		Ingredients:  recipe.Ingredients,
+		Instructions: recipe.Instructions,
+		Servings:     recipe.Servings,
+		Calories:     recipe.Calories,
+		Fat:          recipe.Fat,
+		SatFat:       recipe.SatFat,
+		Carbs:        recipe.Carbs,
+		Fiber:        recipe.Fiber,
+		Sugar:        recipe.Sugar,
+		Protein:      recipe.Protein,
+		CookTime:     recipe.CookTime,
+	}
+}
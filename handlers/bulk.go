@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/wtlow003/recipe-gin-api/bulk"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+	mimeCSV    = "text/csv"
+)
+
+// maxNDJSONLine caps a single NDJSON record; recipe instructions can be long.
+const maxNDJSONLine = 1 << 20
+
+// ExportRecipes	godoc
+// @Summary		Export recipes
+// @Description	stream every recipe as NDJSON, CSV or a JSON array
+// @Tags		recipes
+// @Produce		json
+// @Produce		application/x-ndjson
+// @Produce		text/csv
+// @Param		format	query	string	false	"Export format"	Enums(ndjson, csv, json)	default(ndjson)
+// @Success		200 {array}		models.Recipe
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/export	[get]
+func (handler *RecipesHandler) ExportRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	format := c.DefaultQuery("format", "ndjson")
+	if format != "ndjson" && format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "`format` parameter must be one of ndjson, csv, json.",
+		})
+		return
+	}
+
+	cursor, err := handler.Collection.Find(ctx, bson.M{})
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	// recipes are written as the cursor yields them, so the collection is
+	// never held in memory
+	contentType := map[string]string{"ndjson": mimeNDJSON, "csv": mimeCSV, "json": gin.MIMEJSON}[format]
+	c.Header("Content-Type", contentType+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=recipes.%s", format))
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	encoder := json.NewEncoder(w)
+	csvWriter := csv.NewWriter(w)
+	switch format {
+	case "csv":
+		err = csvWriter.Write(bulk.CSVHeader)
+	case "json":
+		_, err = w.WriteString("[")
+	}
+
+	count := 0
+	for err == nil && cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			log.Error(err)
+			continue
+		}
+		err = writeRecipe(w, encoder, csvWriter, format, recipe, count == 0)
+		count++
+		if err == nil && w.Buffered() > 32<<10 {
+			if err = w.Flush(); err == nil {
+				c.Writer.Flush()
+			}
+		}
+	}
+	if err == nil && format == "json" {
+		_, err = w.WriteString("]")
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = cursor.Err()
+	}
+	if err != nil {
+		// headers are already sent, so the truncated body is all we can
+		// signal; a write error is usually the client going away
+		log.WithField("exported", count).Error(err)
+	}
+}
+
+// writeRecipe writes one exported recipe in format.
+func writeRecipe(w *bufio.Writer, encoder *json.Encoder, csvWriter *csv.Writer, format string, recipe models.Recipe, first bool) error {
+	switch format {
+	case "csv":
+		if err := csvWriter.Write(bulk.ToCSV(recipe)); err != nil {
+			return err
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	case "json":
+		if !first {
+			if _, err := w.WriteString(","); err != nil {
+				return err
+			}
+		}
+	}
+	return encoder.Encode(recipe)
+}
+
+// importRecipes handles the NDJSON and CSV bodies of ImportRecipe. Each
+// row is validated and written on its own so one bad row doesn't fail
+// the whole file.
+func (handler *RecipesHandler) importRecipes(c *gin.Context) {
+	strategy := c.DefaultQuery("strategy", "id")
+	if strategy != "id" && strategy != "name" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "`strategy` parameter must be one of id, name.",
+		})
+		return
+	}
+	report := models.ImportReport{
+		DryRun: c.Query("dryRun") == "true",
+		Errors: make([]models.ImportError, 0),
+	}
+	ids := make([]string, 0)
+	tags := make([]string, 0)
+	owner := c.GetHeader(userHeader)
+
+	importRow := func(row int, recipe models.Recipe, err error) {
+		if err == nil {
+			err = recipe.Validate()
+		}
+		var id primitive.ObjectID
+		var created bool
+		if err == nil {
+			id, created, err = handler.upsertRecipe(c.Request.Context(), recipe, owner, strategy, report.DryRun)
+		}
+		switch {
+		case err != nil:
+			report.Failed++
+			report.Errors = append(report.Errors, models.ImportError{Row: row, Error: err.Error()})
+		case created:
+			report.Created++
+			tags = append(tags, recipe.Tags...)
+		default:
+			report.Updated++
+			ids = append(ids, id.Hex())
+			tags = append(tags, recipe.Tags...)
+		}
+	}
+
+	var err error
+	if c.ContentType() == mimeCSV {
+		err = readCSV(c.Request.Body, importRow)
+	} else {
+		err = readNDJSON(c.Request.Body, importRow)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	// invalidate once for the whole file rather than per row
+	if !report.DryRun && report.Created+report.Updated > 0 {
+		invalidateRecipes(handler.cache, ids, tags)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func readNDJSON(body io.Reader, importRow func(int, models.Recipe, error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), maxNDJSONLine)
+	row := 0
+	for scanner.Scan() {
+		row++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var recipe models.Recipe
+		err := json.Unmarshal(line, &recipe)
+		importRow(row, recipe, err)
+	}
+	return scanner.Err()
+}
+
+func readCSV(body io.Reader, importRow func(int, models.Recipe, error)) error {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if _, ok := err.(*csv.ParseError); ok {
+			importRow(row, models.Recipe{}, err)
+			continue
+		} else if err != nil {
+			return err
+		}
+		recipe, err := bulk.FromCSV(header, record)
+		importRow(row, recipe, err)
+	}
+}
+
+// upsertRecipe stores an imported recipe, matching an existing recipe by
+// ID or by name depending on strategy. It reports whether the recipe was
+// created rather than updated along with its ID; in a dry run nothing is
+// written. Lineage in the file is ignored: an updated recipe keeps its
+// stored owner and fork, and a created one is owned by owner, as with
+// NewRecipe.
+func (handler *RecipesHandler) upsertRecipe(ctx context.Context, recipe models.Recipe, owner string, strategy string, dryRun bool) (primitive.ObjectID, bool, error) {
+	var filter bson.M
+	switch {
+	case strategy == "name":
+		filter = bson.M{"name": recipe.Name}
+	case !recipe.ID.IsZero():
+		filter = bson.M{"_id": recipe.ID}
+	}
+
+	var existing models.Recipe
+	found := false
+	if filter != nil {
+		err := handler.Collection.FindOne(ctx, filter,
+			options.FindOne().SetProjection(bson.M{"_id": 1, "publishedAt": 1, "owner": 1, "forkedFrom": 1}),
+		).Decode(&existing)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return recipe.ID, false, err
+		}
+		found = err == nil
+	}
+
+	if found {
+		recipe.ID = existing.ID
+		if recipe.PublishedAt.IsZero() {
+			recipe.PublishedAt = existing.PublishedAt
+		}
+		recipe.Owner = existing.Owner
+		recipe.ForkedFrom = existing.ForkedFrom
+	} else {
+		if recipe.ID.IsZero() {
+			recipe.ID = primitive.NewObjectID()
+		}
+		recipe.Owner = owner
+		recipe.ForkedFrom = nil
+	}
+	if recipe.PublishedAt.IsZero() {
+		recipe.PublishedAt = time.Now()
+	}
+	handler.classify(&recipe)
+	if dryRun {
+		return recipe.ID, !found, nil
+	}
+
+	if found {
+		_, err := handler.Collection.ReplaceOne(ctx, bson.M{"_id": recipe.ID}, recipe)
+		return recipe.ID, false, err
+	}
+	_, err := handler.Collection.InsertOne(ctx, recipe)
+	return recipe.ID, true, err
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// Invalidation tags. Every cached entry is recorded under the tags of
+// the writes that can change it.
+const (
+	// tagRecipes covers entries that change with any recipe write: list
+	// pages and tag counts.
+	tagRecipes = "recipes"
+	// tagSearch covers every search page, for taxonomy changes that alter
+	// how tags expand.
+	tagSearch = "search"
+	// tagSearchAll covers searches without a tag filter, which any new or
+	// updated recipe may now match.
+	tagSearchAll = "search:all"
+)
+
+// recipeTag covers every entry containing the recipe.
+func recipeTag(id string) string {
+	return "recipe:" + id
+}
+
+// searchTag covers searches whose expanded tags include tag.
+func searchTag(tag string) string {
+	return "search:tag:" + tag
+}
+
+// recipeKey caches a single recipe.
+func recipeKey(id string) string {
+	return "recipes:" + id
+}
+
+// tagsKey caches the tag counts.
+const tagsKey = "tags"
+
+func listKey(fields []string, order bson.D) string {
+	return "recipes:list:" + fieldsKey(fields) + "|" + sortKey(order)
+}
+
+func searchKey(tag string, excludeAllergens []string, fields []string, order bson.D) string {
+	excluded := append(make([]string, 0, len(excludeAllergens)), excludeAllergens...)
+	sort.Strings(excluded)
+	return "recipes:search:" + tag + "|" + strings.Join(excluded, ",") + "|" + fieldsKey(fields) + "|" + sortKey(order)
+}
+
+// invalidateRecipes clears the entries affected by writing recipes: the
+// recipes' own entries, every list, and the searches they may now match
+// through tags.
+func invalidateRecipes(c *cache.Cache, ids []string, tags []string) {
+	invalidation := []string{tagRecipes, tagSearchAll}
+	for _, id := range ids {
+		invalidation = append(invalidation, recipeTag(id))
+	}
+	for _, tag := range tags {
+		invalidation = append(invalidation, searchTag(tag))
+	}
+	log.Println("Remove data from Redis")
+	if err := c.Invalidate(invalidation...); err != nil {
+		log.Error(err)
+	}
+}
+
+// writePayload writes a cached JSON payload as is. A compressed payload
+// is only decompressed for clients that don't accept gzip.
+func writePayload(c *gin.Context, payload []byte) {
+	c.Header("Vary", "Accept-Encoding")
+	if cache.Compressed(payload) {
+		if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Header("Content-Encoding", "gzip")
+		} else {
+			data, err := cache.Decode(payload)
+			if err != nil {
+				serverError(c, err)
+				return
+			}
+			payload = data
+		}
+	}
+	c.Data(http.StatusOK, gin.MIMEJSON+"; charset=utf-8", payload)
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip,
+// either by name or through a wildcard.
+func acceptsGzip(header string) bool {
+	gzip, wildcard := -1.0, -1.0
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				q, _ = strconv.ParseFloat(value, 64)
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			gzip = q
+		case "*":
+			wildcard = q
+		}
+	}
+	if gzip < 0 {
+		return wildcard > 0
+	}
+	return gzip > 0
+}
+
+// cachedRecipes reads the given recipes from the cache, returning those
+// that were cached. A cache failure is treated as a miss for every ID.
+func cachedRecipes(ctx context.Context, c *cache.Cache, ids []string) map[string]models.Recipe {
+	found := make(map[string]models.Recipe)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = recipeKey(id)
+	}
+	values, err := c.GetMany(ctx, keys)
+	if err != nil {
+		log.Error(err)
+		return found
+	}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		var recipe models.Recipe
+		if err := json.Unmarshal(value, &recipe); err == nil {
+			found[ids[i]] = recipe
+		}
+	}
+	return found
+}
+
+// cacheRecipes stores recipes under their per-recipe keys in one round
+// trip, unless they were invalidated after the cache's clock read since,
+// which callers take before loading them.
+func cacheRecipes(ctx context.Context, c *cache.Cache, since int64, recipes []models.Recipe) {
+	entries := make([]cache.Entry, len(recipes))
+	for i, recipe := range recipes {
+		data, _ := json.Marshal(recipe)
+		id := recipe.ID.Hex()
+		entries[i] = cache.Entry{Key: recipeKey(id), Value: data, Tags: []string{recipeTag(id)}}
+	}
+	if err := c.SetManyIfCurrent(ctx, entries, c.TTL.Recipe, since); err != nil {
+		log.Error(err)
+	}
+}
+
+// taggedRecipes returns the invalidation tags of every recipe carrying
+// one of tags, collected before the tags are rewritten.
+func taggedRecipes(ctx context.Context, collection *mongo.Collection, tags []string) ([]string, error) {
+	cursor, err := collection.Find(ctx,
+		bson.M{"tags": bson.M{"$in": tags}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invalidation := make([]string, 0)
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			return nil, err
+		}
+		invalidation = append(invalidation, recipeTag(recipe.ID.Hex()))
+	}
+	return invalidation, cursor.Err()
+}
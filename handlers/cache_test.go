@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// BenchmarkWritePayload measures serving a cached recipe list, against
+// the baseline of decoding the cached JSON and encoding it again.
+func BenchmarkWritePayload(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+	data, err := os.ReadFile("../recipes.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var recipes []models.Recipe
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		b.Fatal(err)
+	}
+	plain, _ := cache.New(nil, cache.Options{}).Encode(recipes)
+	compressed, _ := cache.New(nil, cache.Options{Compress: true}).Encode(recipes)
+
+	for _, bc := range []struct {
+		name           string
+		acceptEncoding string
+		serve          func(c *gin.Context)
+	}{
+		{"baseline", "", func(c *gin.Context) {
+			var decoded []models.Recipe
+			json.Unmarshal(plain, &decoded)
+			c.JSON(http.StatusOK, decoded)
+		}},
+		{"plain", "", func(c *gin.Context) { writePayload(c, plain) }},
+		{"gzip", "gzip", func(c *gin.Context) { writePayload(c, compressed) }},
+		{"gzip-to-plain-client", "", func(c *gin.Context) { writePayload(c, compressed) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/recipes", nil)
+			if bc.acceptEncoding != "" {
+				request.Header.Set("Accept-Encoding", bc.acceptEncoding)
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = request
+				bc.serve(c)
+				if w.Code != http.StatusOK {
+					b.Fatalf("status %d", w.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// maxCacheKeys caps the keys listed by ListCacheKeys.
+const maxCacheKeys = 1000
+
+type CacheHandler struct {
+	cache   *cache.Cache
+	recipes *RecipesHandler
+	tags    *TagsHandler
+	// top is how many recipes a warm-up caches by default.
+	top int
+}
+
+func NewCacheHandler(cache *cache.Cache, recipes *RecipesHandler, tags *TagsHandler, top int) *CacheHandler {
+	return &CacheHandler{
+		cache:   cache,
+		recipes: recipes,
+		tags:    tags,
+		top:     top,
+	}
+}
+
+// ListCacheKeys	godoc
+// @Summary		List cache keys
+// @Description	get the entries cached in Redis with their size and time to live, and the size of this replica's in-process tier
+// @Tags		admin
+// @Produce		json
+// @Security	BasicAuth
+// @Param		prefix	query	string	false	"Only keys starting with prefix, e.g. recipes:list:"
+// @Param		limit	query	int		false	"Maximum number of keys"	default(100)
+// @Success		200 {object}	models.CacheKeys
+// @Failure		400	{object}	models.Error
+// @Failure		503	{object}	models.Error
+// @Router		/admin/cache/keys [get]
+func (handler *CacheHandler) ListCacheKeys(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > maxCacheKeys {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      fmt.Sprintf("`limit` parameter must be between 1 and %d.", maxCacheKeys),
+		})
+		return
+	}
+
+	keys, err := handler.cache.Keys(c.Query("prefix"), limit)
+	if err != nil {
+		cacheError(c, err)
+		return
+	}
+	entries, bytes := handler.cache.LocalStats()
+	response := models.CacheKeys{
+		Local: models.CacheTier{Entries: entries, Bytes: bytes},
+		Keys:  make([]models.CacheKey, len(keys)),
+	}
+	for i, key := range keys {
+		response.Keys[i] = models.CacheKey{
+			Key:   key.Key,
+			Bytes: key.Bytes,
+			TTL:   key.TTL.Seconds(),
+			Stale: key.Stale,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// FlushCache	godoc
+// @Summary		Flush cache
+// @Description	delete cached entries, from Redis and every replica's in-process tier. Without parameters the whole cache is flushed.
+// @Tags		admin
+// @Produce		json
+// @Security	BasicAuth
+// @Param		prefix	query	string	false	"Only keys starting with prefix, e.g. recipes:search:"
+// @Param		tags	query	string	false	"Comma-separated invalidation tags, e.g. recipes,recipe:64d236d01af83c4f1209cdcf"
+// @Success		200 {object}	models.CacheFlush
+// @Failure		400	{object}	models.Error
+// @Failure		503	{object}	models.Error
+// @Router		/admin/cache [delete]
+func (handler *CacheHandler) FlushCache(c *gin.Context) {
+	prefix := c.Query("prefix")
+	tags := splitQuery(c.Query("tags"))
+	if prefix != "" && len(tags) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "Flush by either `prefix` or `tags`, not both.",
+		})
+		return
+	}
+
+	var deleted int
+	var err error
+	if len(tags) > 0 {
+		deleted, err = handler.cache.FlushTags(tags...)
+	} else {
+		deleted, err = handler.cache.Flush(prefix)
+	}
+	if err != nil {
+		cacheError(c, err)
+		return
+	}
+	log.Infof("Flushed %d cache entries", deleted)
+	c.JSON(http.StatusOK, models.CacheFlush{Deleted: deleted})
+}
+
+// WarmCache	godoc
+// @Summary		Warm up cache
+// @Description	cache the default recipe list, the tag counts and the top-rated recipes. Entries already cached are left as they are.
+// @Tags		admin
+// @Produce		json
+// @Security	BasicAuth
+// @Param		top	query	int	false	"Number of top-rated recipes to cache"
+// @Success		200 {object}	models.CacheWarmup
+// @Failure		400	{object}	models.Error
+// @Router		/admin/cache/warmup [post]
+func (handler *CacheHandler) WarmCache(c *gin.Context) {
+	top := handler.top
+	if value, ok := c.GetQuery("top"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": http.StatusBadRequest,
+				"error":      "`top` parameter must be a non-negative integer.",
+			})
+			return
+		}
+		top = n
+	}
+	c.JSON(http.StatusOK, handler.Warm(c.Request.Context(), top))
+}
+
+// Warm pre-populates the cache with the default recipe list, the tag
+// counts and the top recipes by rating, so the first requests after a
+// start or a flush are not all sent to MongoDB.
+func (handler *CacheHandler) Warm(ctx context.Context, top int) models.CacheWarmup {
+	warmup := models.CacheWarmup{
+		Keys:   make([]string, 0),
+		Errors: make([]string, 0),
+	}
+	fail := func(err error) {
+		log.Error(err)
+		warmup.Errors = append(warmup.Errors, err.Error())
+	}
+
+	fields, _ := parseFields("summary")
+	order, _ := parseSort("")
+	if _, err := handler.recipes.listPage(ctx, fields, order); err != nil {
+		fail(err)
+	} else {
+		warmup.Keys = append(warmup.Keys, listKey(fields, order))
+	}
+
+	if _, err := handler.tags.tagCounts(ctx); err != nil {
+		fail(err)
+	} else {
+		warmup.Keys = append(warmup.Keys, tagsKey)
+	}
+
+	if top > 0 {
+		since, err := handler.cache.Clock(ctx)
+		var recipes []models.Recipe
+		if err == nil {
+			recipes, err = handler.recipes.topRecipes(ctx, top)
+		}
+		if err != nil {
+			fail(err)
+		} else {
+			cacheRecipes(ctx, handler.cache, since, recipes)
+			for _, recipe := range recipes {
+				warmup.Keys = append(warmup.Keys, recipeKey(recipe.ID.Hex()))
+			}
+		}
+	}
+	log.Infof("Warmed up %d cache entries", len(warmup.Keys))
+	return warmup
+}
+
+// topRecipes returns the n best rated recipes.
+func (handler *RecipesHandler) topRecipes(ctx context.Context, n int) ([]models.Recipe, error) {
+	cursor, err := handler.Collection.Find(ctx, bson.M{},
+		options.Find().
+			SetSort(bson.D{{Key: "rating", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(n)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	recipes := make([]models.Recipe, 0)
+	if err := cursor.All(ctx, &recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
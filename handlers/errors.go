@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/wtlow003/recipe-gin-api/cache"
+)
+
+// serverError responds to an unexpected error with 500, or with 504 when
+// the request ran out of time.
+func serverError(c *gin.Context, err error) {
+	if timeoutError(c, err) {
+		return
+	}
+	log.Error(err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"statusCode": http.StatusInternalServerError,
+		"error":      err.Error(),
+	})
+}
+
+// timeoutError responds with 504 and returns true when err is the
+// request's deadline passing, in MongoDB, in Redis or while waiting on
+// another request's query.
+func timeoutError(c *gin.Context, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) && !mongo.IsTimeout(err) {
+		return false
+	}
+	log.WithField("route", c.FullPath()).Warn(err)
+	c.JSON(http.StatusGatewayTimeout, gin.H{
+		"statusCode": http.StatusGatewayTimeout,
+		"error":      "The request timed out.",
+	})
+	return true
+}
+
+// cacheError responds to a failed cache operation, with 503 while Redis
+// is unavailable.
+func cacheError(c *gin.Context, err error) {
+	if err == cache.ErrUnavailable {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"statusCode": http.StatusServiceUnavailable,
+			"error":      err.Error(),
+		})
+		return
+	}
+	serverError(c, err)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// recipeField maps a JSON field of models.Recipe to its stored name and
+// how to read it back out of a decoded recipe.
+type recipeField struct {
+	bson  string
+	value func(models.Recipe) interface{}
+}
+
+var recipeFields = map[string]recipeField{
+	"id":           {"_id", func(r models.Recipe) interface{} { return r.ID }},
+	"name":         {"name", func(r models.Recipe) interface{} { return r.Name }},
+	"tags":         {"tags", func(r models.Recipe) interface{} { return r.Tags }},
+	"ingredients":  {"ingredients", func(r models.Recipe) interface{} { return r.Ingredients }},
+	"instructions": {"instruction", func(r models.Recipe) interface{} { return r.Instructions }},
+	"servings":     {"servings", func(r models.Recipe) interface{} { return r.Servings }},
+	"calories":     {"calories", func(r models.Recipe) interface{} { return r.Calories }},
+	"fat":          {"fat", func(r models.Recipe) interface{} { return r.Fat }},
+	"satfat":       {"satfat", func(r models.Recipe) interface{} { return r.SatFat }},
+	"carbs":        {"carbs", func(r models.Recipe) interface{} { return r.Carbs }},
+	"fiber":        {"fiber", func(r models.Recipe) interface{} { return r.Fiber }},
+	"sugar":        {"sugar", func(r models.Recipe) interface{} { return r.Sugar }},
+	"protein":      {"proten", func(r models.Recipe) interface{} { return r.Protein }},
+	"cookTime":     {"cookTime", func(r models.Recipe) interface{} { return r.CookTime }},
+	"rating":       {"rating", func(r models.Recipe) interface{} { return r.Rating }},
+	"allergens":    {"allergens", func(r models.Recipe) interface{} { return r.Allergens }},
+	"diets":        {"diets", func(r models.Recipe) interface{} { return r.Diets }},
+	"owner":        {"owner", func(r models.Recipe) interface{} { return r.Owner }},
+	"forkedFrom":   {"forkedFrom", func(r models.Recipe) interface{} { return r.ForkedFrom }},
+	"publishedAt":  {"publishedAt", func(r models.Recipe) interface{} { return r.PublishedAt }},
+}
+
+// summaryFields is the default representation of recipes in list views,
+// matching models.RecipeSummary.
+var summaryFields = []string{"id", "name", "tags", "servings", "calories", "allergens", "diets", "publishedAt"}
+
+// listFields reads the `fields` parameter of a list endpoint. It defaults
+// to the summary fields; "summary" may also be combined with others. The
+// result is sorted and always includes id.
+func listFields(c *gin.Context) ([]string, error) {
+	return parseFields(c.DefaultQuery("fields", "summary"))
+}
+
+// parseFields reads a comma-separated field selection.
+func parseFields(value string) ([]string, error) {
+	requested := splitQuery(value)
+	if len(requested) == 0 {
+		requested = []string{"summary"}
+	}
+
+	selected := map[string]bool{"id": true}
+	for _, field := range requested {
+		if field == "summary" {
+			for _, f := range summaryFields {
+				selected[f] = true
+			}
+			continue
+		}
+		if _, ok := recipeFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in `fields`", field)
+		}
+		selected[field] = true
+	}
+
+	fields := make([]string, 0, len(selected))
+	for field := range selected {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// fieldsKey identifies a field selection in cache keys.
+func fieldsKey(fields []string) string {
+	return strings.Join(fields, ",")
+}
+
+// projection limits a query to the stored names of fields.
+func projection(fields []string) bson.M {
+	p := bson.M{}
+	for _, field := range fields {
+		p[recipeFields[field].bson] = 1
+	}
+	return p
+}
+
+// project keeps only fields of each recipe, so unselected fields are
+// left out of the response rather than sent as zero values.
+func project(recipes []models.Recipe, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, len(recipes))
+	for i, recipe := range recipes {
+		doc := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			doc[field] = recipeFields[field].value(recipe)
+		}
+		projected[i] = doc
+	}
+	return projected
+}
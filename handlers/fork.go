@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/wtlow003/recipe-gin-api/middlewares"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// userHeader carries the caller's identity, verified by
+// middlewares.Identity.
+const userHeader = middlewares.UserHeader
+
+// ForkIndexes are the indexes backing ListForks. Most recipes aren't
+// forks, so the index is sparse.
+func ForkIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "forkedFrom", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	}}
+}
+
+// ForkRecipe	godoc
+// @Summary		Fork recipe
+// @Description	copy a recipe into a new recipe owned by the caller
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		id			path 	string	true 	"Recipe ID"
+// @Param		X-User-ID	header	string	true	"Caller's user ID"
+// @Param		X-User-Signature	header	string	true	"Hex HMAC-SHA256 of X-User-ID, signed by the gateway"
+// @Success		200 {object}	models.Recipe
+// @Failure		400	{object}	models.Error
+// @Failure		401	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/{id}/fork	[post]
+func (handler *RecipesHandler) ForkRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	owner := c.GetHeader(userHeader)
+	if owner == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"statusCode": http.StatusUnauthorized,
+			"error":      "`X-User-ID` header is required.",
+		})
+		return
+	}
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	var recipe models.Recipe
+	err = handler.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&recipe)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      err.Error(),
+		})
+		return
+	} else if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	recipe.ID = primitive.NewObjectID()
+	recipe.Owner = owner
+	recipe.ForkedFrom = &objectId
+	recipe.PublishedAt = time.Now()
+	if _, err := handler.Collection.InsertOne(ctx, recipe); err != nil {
+		if timeoutError(c, err) {
+			return
+		}
+		log.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": http.StatusInternalServerError,
+			"error":      "Error inserting a new recipe!",
+		})
+		return
+	}
+
+	invalidateRecipes(handler.cache, nil, recipe.Tags)
+
+	c.JSON(http.StatusOK, recipe)
+}
+
+// ListForks	godoc
+// @Summary		List recipe forks
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		id		path 		string	true 	"Recipe ID"
+// @Param		fields	query		string	false	"Comma-separated recipe fields; `summary` selects the default set"	default(summary)
+// @Success		200 {array}		models.RecipeSummary
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/{id}/forks	[get]
+func (handler *RecipesHandler) ListForks(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	fields, err := listFields(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	cursor, err := handler.Collection.Find(ctx, bson.M{"forkedFrom": objectId},
+		options.Find().SetProjection(projection(fields)),
+	)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	recipes := make([]models.Recipe, 0)
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			serverError(c, err)
+			return
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := cursor.Err(); err != nil {
+		serverError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, project(recipes, fields))
+}
+
+// DiffRecipe	godoc
+// @Summary		Diff fork against parent
+// @Description	show how a fork's ingredients and instructions differ from the recipe it was forked from
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		id	path 		string	true 	"Forked recipe ID"
+// @Success		200 {object}	models.RecipeDiff
+// @Failure		400	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		422	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/{id}/diff	[get]
+func (handler *RecipesHandler) DiffRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	var fork models.Recipe
+	err = handler.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&fork)
+	if err == nil && fork.ForkedFrom == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "Recipe is not a fork.",
+		})
+		return
+	}
+	var parent models.Recipe
+	if err == nil {
+		err = handler.Collection.FindOne(ctx, bson.M{"_id": *fork.ForkedFrom}).Decode(&parent)
+	}
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      err.Error(),
+		})
+		return
+	} else if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	instructions, err := diffLines(splitLines(parent.Instructions), splitLines(fork.Instructions))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"error":      err.Error(),
+		})
+		return
+	}
+	added, removed := diffIngredients(parent.Ingredients, fork.Ingredients)
+	c.JSON(http.StatusOK, models.RecipeDiff{
+		ID:                 fork.ID,
+		ForkedFrom:         parent.ID,
+		AddedIngredients:   added,
+		RemovedIngredients: removed,
+		Instructions:       instructions,
+	})
+}
+
+// diffIngredients compares ingredient lists as multisets, ignoring order
+// and surrounding whitespace.
+func diffIngredients(parent, fork []string) (added []string, removed []string) {
+	remaining := make(map[string]int)
+	for _, line := range parent {
+		remaining[strings.TrimSpace(line)]++
+	}
+	added = make([]string, 0)
+	for _, line := range fork {
+		line = strings.TrimSpace(line)
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		added = append(added, line)
+	}
+	removed = make([]string, 0)
+	for _, line := range parent {
+		line = strings.TrimSpace(line)
+		if remaining[line] > 0 {
+			remaining[line]--
+			removed = append(removed, line)
+		}
+	}
+	return added, removed
+}
+
+func splitLines(text string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// maxDiffLines bounds the lines on either side that diffLines compares
+// once their common prefix and suffix are set aside, since the comparison
+// takes time and memory proportional to their product.
+const maxDiffLines = 1000
+
+var errDiffTooLong = fmt.Errorf("instructions differ in more than %d lines", maxDiffLines)
+
+// diffLines produces a line diff from the longest common subsequence of
+// the two texts. Lines shared at the start and end are matched up front;
+// it fails with errDiffTooLong if more than maxDiffLines remain on either
+// side.
+func diffLines(a, b []string) ([]models.DiffLine, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(middleA) > maxDiffLines || len(middleB) > maxDiffLines {
+		return nil, errDiffTooLong
+	}
+
+	lines := make([]models.DiffLine, 0, len(a)+len(middleB))
+	for _, line := range a[:prefix] {
+		lines = append(lines, models.DiffLine{Op: "equal", Text: line})
+	}
+	lines = append(lines, lcsDiff(middleA, middleB)...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, models.DiffLine{Op: "equal", Text: line})
+	}
+	return lines, nil
+}
+
+// lcsDiff diffs a and b through a table of their longest common
+// subsequences.
+func lcsDiff(a, b []string) []models.DiffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]models.DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, models.DiffLine{Op: "equal", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, models.DiffLine{Op: "delete", Text: a[i]})
+			i++
+		default:
+			lines = append(lines, models.DiffLine{Op: "insert", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, models.DiffLine{Op: "delete", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, models.DiffLine{Op: "insert", Text: b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/wtlow003/recipe-gin-api/export"
+	"github.com/wtlow003/recipe-gin-api/models"
+	"github.com/wtlow003/recipe-gin-api/schemaorg"
+)
+
+const (
+	formatJSON     = "json"
+	formatJSONLD   = "jsonld"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+)
+
+const (
+	mimeJSONLD   = "application/ld+json"
+	mimeMarkdown = "text/markdown"
+)
+
+// recipeFormat picks the output format from the `format` parameter, or
+// from the Accept header when the parameter is absent.
+func recipeFormat(c *gin.Context) (string, bool) {
+	if format := c.Query("format"); format != "" {
+		switch format {
+		case formatJSON, formatJSONLD, formatMarkdown, formatHTML:
+			return format, true
+		case "md":
+			return formatMarkdown, true
+		}
+		return "", false
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, mimeJSONLD, mimeMarkdown, gin.MIMEHTML) {
+	case mimeJSONLD:
+		return formatJSONLD, true
+	case mimeMarkdown:
+		return formatMarkdown, true
+	case gin.MIMEHTML:
+		return formatHTML, true
+	}
+	return formatJSON, true
+}
+
+func renderRecipe(c *gin.Context, format string, recipe models.Recipe) {
+	var b bytes.Buffer
+	var err error
+	contentType := gin.MIMEJSON
+	switch format {
+	case formatJSONLD:
+		contentType = mimeJSONLD
+		err = json.NewEncoder(&b).Encode(schemaorg.FromRecipe(recipe))
+	case formatMarkdown:
+		contentType = mimeMarkdown
+		err = export.Markdown(&b, recipe)
+	case formatHTML:
+		contentType = gin.MIMEHTML
+		err = export.HTML(&b, recipe)
+	default:
+		c.JSON(http.StatusOK, recipe)
+		return
+	}
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", b.Bytes())
+}
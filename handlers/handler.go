@@ -2,85 +2,111 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
-	"github.com/go-redis/redis"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/ingredients"
 	"github.com/wtlow003/recipe-gin-api/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slices"
 )
 
 type RecipesHandler struct {
-	Collection  *mongo.Collection
-	Ctx         context.Context
-	redisClient *redis.Client
+	Collection     *mongo.Collection
+	TagsCollection *mongo.Collection
+	cache          *cache.Cache
+	classifier     *ingredients.Classifier
 }
 
 // ListRecipes		godoc
 //
 // @Summary		List recipes
-// @Description	get all recipes
+// @Description	get all recipes as summaries, or the fields chosen with `fields`. With `ids`, the given recipes are returned in full in request order as models.RecipeLookupResult.
 // @Tags		recipes
 // @Accept		json
 // @Produce		json
-// @Success		200	{array}		models.Recipe
+// @Param		fields	query	string	false	"Comma-separated recipe fields, e.g. name,tags,calories; `summary` selects the default set"	default(summary)
+// @Param		ids		query	string	false	"Comma-separated recipe IDs to look up"
+// @Param		sort	query	string	false	"Comma-separated sort keys, `-` for descending, e.g. -publishedAt,name. One of publishedAt, name, calories, fat, satfat, carbs, fiber, sugar, protein, rating, cookTime"
+// @Success		200	{array}		models.RecipeSummary
+// @Failure		400	{object}	models.Error
 // @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
 // @Router		/recipes [get]
-func NewRecipesHandler(ctx context.Context, collection *mongo.Collection, redisClient *redis.Client) *RecipesHandler {
+func NewRecipesHandler(collection *mongo.Collection, tagsCollection *mongo.Collection, cache *cache.Cache, classifier *ingredients.Classifier) *RecipesHandler {
 	return &RecipesHandler{
-		Collection:  collection,
-		Ctx:         ctx,
-		redisClient: redisClient,
+		Collection:     collection,
+		TagsCollection: tagsCollection,
+		cache:          cache,
+		classifier:     classifier,
 	}
 }
 
 // Defining receiver functions for `RecipesHandler`
 func (handler *RecipesHandler) ListRecipes(c *gin.Context) {
-	// look for hit in redis cache first
-	val, err := handler.redisClient.Get("recipes").Result()
-	if err == redis.Nil {
+	if ids, ok := c.GetQuery("ids"); ok {
+		handler.lookupRecipes(c, splitQuery(ids))
+		return
+	}
+	var order bson.D
+	fields, err := listFields(c)
+	if err == nil {
+		order, err = listSort(c)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	data, err := handler.listPage(c.Request.Context(), fields, order)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	writePayload(c, data)
+}
+
+// listPage returns the encoded list of recipes with the given fields and
+// order. It is served from the cache; concurrent misses share a single
+// query.
+func (handler *RecipesHandler) listPage(ctx context.Context, fields []string, order bson.D) ([]byte, error) {
+	return handler.cache.Fetch(ctx, listKey(fields, order), handler.cache.TTL.List, func(ctx context.Context) ([]byte, []string, error) {
 		log.Println("Request to MongoDB")
-		// `collection` assigned in `init()`
-		cursor, err := handler.Collection.Find(handler.Ctx, bson.M{})
+		// `collection` assigned in `setup()`
+		recipes, err := handler.findRecipes(ctx, bson.M{}, fields, order)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"statusCode": http.StatusInternalServerError,
-				"error":      err.Error(),
-			})
-		}
-		defer cursor.Close(handler.Ctx)
-
-		recipes := make([]models.Recipe, 0)
-		for cursor.Next(handler.Ctx) {
-			var recipe models.Recipe
-			cursor.Decode(&recipe)
-			recipes = append(recipes, recipe)
+			return nil, nil, err
 		}
+		data, err := handler.cache.Encode(project(recipes, fields))
+		return data, []string{tagRecipes}, err
+	})
+}
 
-		// store in redis for later hits
-		data, _ := json.Marshal(recipes)
-		handler.redisClient.Set("recipes", string(data), 0)
-		c.JSON(http.StatusOK, recipes)
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": http.StatusInternalServerError,
-			"error":      err.Error(),
-		})
-		return
-	} else {
-		// if redis hit
-		log.Println("Request to Redis")
-		recipes := make([]models.Recipe, 0)
-		json.Unmarshal([]byte(val), &recipes)
-		c.JSON(http.StatusOK, recipes)
+// findRecipes runs a list query with the given field selection and order.
+func (handler *RecipesHandler) findRecipes(ctx context.Context, filter bson.M, fields []string, order bson.D) ([]models.Recipe, error) {
+	cursor, err := handler.Collection.Find(ctx, filter,
+		options.Find().SetProjection(projection(fields)).SetSort(order),
+	)
+	if err != nil {
+		return nil, err
 	}
+	recipes := make([]models.Recipe, 0)
+	if err := cursor.All(ctx, &recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
 }
 
 // NewRecipe		godoc
@@ -94,8 +120,10 @@ func (handler *RecipesHandler) ListRecipes(c *gin.Context) {
 // @Success			200 {object}	models.Recipe
 // @Failure			400 {object}	models.Error
 // @Failure			500 {object}	models.Error
+// @Failure		504	{object}	models.Error
 // @Router			/recipes [post]
 func (handler *RecipesHandler) NewRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
 	var recipe models.Recipe
 	// bind request body into `Recipe` struct
 	if err := c.ShouldBindJSON(&recipe); err != nil {
@@ -109,8 +137,14 @@ func (handler *RecipesHandler) NewRecipe(c *gin.Context) {
 
 	recipe.ID = primitive.NewObjectID()
 	recipe.PublishedAt = time.Now()
-	_, err := handler.Collection.InsertOne(handler.Ctx, recipe)
+	handler.classify(&recipe)
+	recipe.Owner = c.GetHeader(userHeader)
+	recipe.ForkedFrom = nil
+	_, err := handler.Collection.InsertOne(ctx, recipe)
 	if err != nil {
+		if timeoutError(c, err) {
+			return
+		}
 		log.Error(err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"statusCode": http.StatusInternalServerError,
@@ -119,8 +153,7 @@ func (handler *RecipesHandler) NewRecipe(c *gin.Context) {
 		return
 	}
 
-	log.Println("Remove data from Redis")
-	handler.redisClient.Del("recipes")
+	invalidateRecipes(handler.cache, nil, recipe.Tags)
 
 	// successful
 	c.JSON(http.StatusOK, recipe)
@@ -136,8 +169,10 @@ func (handler *RecipesHandler) NewRecipe(c *gin.Context) {
 // @Success		200 {object}	models.Message
 // @Failure		400	{object}	models.Error
 // @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
 // @Router		/recipes/{id}	[put]
 func (handler *RecipesHandler) UpdateRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
 	// recipe id
 	id, found := c.Params.Get("id")
 	if !found {
@@ -165,29 +200,14 @@ func (handler *RecipesHandler) UpdateRecipe(c *gin.Context) {
 		})
 		return
 	}
-	_, err = handler.Collection.UpdateOne(
-		handler.Ctx,
-		bson.M{"_id": objectId},
-		bson.D{{
-			Key: "$set", Value: bson.D{
-				{Key: "name", Value: recipe.Name},
-				{Key: "instructions", Value: recipe.Instructions},
-				{Key: "ingredients", Value: recipe.Ingredients},
-				{Key: "tags", Value: recipe.Tags},
-			},
-		}},
-	)
+	handler.classify(&recipe)
+	_, err = handler.Collection.UpdateOne(ctx, bson.M{"_id": objectId}, recipeUpdate(recipe))
 	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": http.StatusInternalServerError,
-			"error":      err.Error(),
-		})
+		serverError(c, err)
 		return
 	}
 
-	log.Println("Remove data from Redis")
-	handler.redisClient.Del("recipes")
+	invalidateRecipes(handler.cache, []string{objectId.Hex()}, recipe.Tags)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Recipe has been updated!",
@@ -195,18 +215,50 @@ func (handler *RecipesHandler) UpdateRecipe(c *gin.Context) {
 
 }
 
+// classify derives the recipe's allergens and diets from its ingredients,
+// recording the version of the rules used.
+func (handler *RecipesHandler) classify(recipe *models.Recipe) {
+	recipe.Allergens, recipe.Diets = handler.classifier.Classify(recipe.Ingredients)
+	recipe.ClassifiedWith = handler.classifier.Version
+}
+
+// recipeUpdate is the update document shared by UpdateRecipe and batch
+// updates. Keys are the bson field names of models.Recipe, e.g.
+// "instruction", not the JSON names.
+func recipeUpdate(recipe models.Recipe) bson.D {
+	return bson.D{{
+		Key: "$set", Value: bson.D{
+			{Key: "name", Value: recipe.Name},
+			{Key: "instruction", Value: recipe.Instructions},
+			{Key: "ingredients", Value: recipe.Ingredients},
+			{Key: "tags", Value: recipe.Tags},
+			{Key: "cookTime", Value: recipe.CookTime},
+			{Key: "allergens", Value: recipe.Allergens},
+			{Key: "diets", Value: recipe.Diets},
+			{Key: "classifiedWith", Value: recipe.ClassifiedWith},
+		},
+	}}
+}
+
 // ListRecipe	godoc
 // @Summary		List recipe
+// @Description	get a recipe as JSON, schema.org JSON-LD, Markdown or a printable HTML page, chosen by `format` or the Accept header
 // @Tags		recipes
 // @Accept		json
 // @Produce		json
-// @Param		id	path 		string	true 	"Recipe ID"
+// @Produce		application/ld+json
+// @Produce		text/markdown
+// @Produce		html
+// @Param		id		path 		string	true 	"Recipe ID"
+// @Param		format	query		string	false	"Output format"	Enums(json, jsonld, markdown, html)
 // @Success		200 {object}	models.Recipe
 // @Failure		400 {object}	models.Error
 // @Failure		404	{object}	models.Error
 // @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
 // @Router		/recipes/{id}	[get]
 func (handler *RecipesHandler) ListRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
 	id, found := c.Params.Get("id")
 	if !found {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -223,10 +275,29 @@ func (handler *RecipesHandler) ListRecipe(c *gin.Context) {
 			"statusCode": http.StatusBadRequest,
 			"error":      err.Error(),
 		})
+		return
 	}
 
-	var recipe models.Recipe
-	err = handler.Collection.FindOne(handler.Ctx,
+	format, ok := recipeFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "`format` parameter must be one of json, jsonld, markdown, html.",
+		})
+		return
+	}
+
+	// read through the per-recipe cache
+	recipe, ok := cachedRecipes(ctx, handler.cache, []string{objectId.Hex()})[objectId.Hex()]
+	if ok {
+		log.Println("Request to Redis")
+		renderRecipe(c, format, recipe)
+		return
+	}
+
+	log.Println("Request to MongoDB")
+	since, clockErr := handler.cache.Clock(ctx)
+	err = handler.Collection.FindOne(ctx,
 		bson.M{"_id": objectId},
 	).Decode(&recipe)
 	if err != nil {
@@ -239,10 +310,16 @@ func (handler *RecipesHandler) ListRecipe(c *gin.Context) {
 			return
 		}
 		// unknown error
-		log.Error(err)
+		serverError(c, err)
+		return
+	}
+	if clockErr != nil {
+		log.Error(clockErr)
+	} else {
+		cacheRecipes(ctx, handler.cache, since, []models.Recipe{recipe})
 	}
 
-	c.JSON(http.StatusOK, recipe)
+	renderRecipe(c, format, recipe)
 }
 
 // DeleteRecipe	godoc
@@ -254,8 +331,10 @@ func (handler *RecipesHandler) ListRecipe(c *gin.Context) {
 // @Success		200 {object}	models.Message
 // @Failure		400	{object}	models.Error
 // @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
 // @Router		/recipes/{id}	[delete]
 func (handler *RecipesHandler) DeleteRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
 	id, found := c.Params.Get("id")
 	if !found {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -272,18 +351,17 @@ func (handler *RecipesHandler) DeleteRecipe(c *gin.Context) {
 			"statusCode": http.StatusBadRequest,
 			"error":      err.Error(),
 		})
+		return
 	}
 
-	res, err := handler.Collection.DeleteOne(handler.Ctx, bson.M{"_id": objectId})
+	res, err := handler.Collection.DeleteOne(ctx, bson.M{"_id": objectId})
 	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": http.StatusInternalServerError,
-			"error":      err.Error(),
-		})
+		serverError(c, err)
 		return
 	}
 
+	invalidateRecipes(handler.cache, []string{objectId.Hex()}, nil)
+
 	format := "Deleted %d recipe!"
 	c.JSON(http.StatusOK, gin.H{
 		"message": fmt.Sprintf(format, res.DeletedCount),
@@ -295,39 +373,97 @@ func (handler *RecipesHandler) DeleteRecipe(c *gin.Context) {
 // @Tags		recipes
 // @Accept		json
 // @Produce		json
-// @Param		tag	query 		string	true 	"Recipe search by tag"
-// @Success		200 {array}		models.Recipe
+// @Param		tag					query 		string	false 	"Recipe search by tag"
+// @Param		excludeAllergens	query 		string	false 	"Comma-separated allergens to exclude, e.g. nuts,dairy"
+// @Param		fields				query		string	false	"Comma-separated recipe fields; `summary` selects the default set"	default(summary)
+// @Param		sort				query		string	false	"Comma-separated sort keys, `-` for descending, e.g. -publishedAt,name. One of publishedAt, name, calories, fat, satfat, carbs, fiber, sugar, protein, rating, cookTime"
+// @Success		200 {array}		models.RecipeSummary
 // @Failure		400	{object}	models.Error
 // @Failure		500 {object}	models.Error
+// @Failure		504	{object}	models.Error
 // @Router		/recipes/search	[get]
 func (handler *RecipesHandler) SearchRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
 	tag := c.Query("tag")
-	if tag == "" {
+	excludeAllergens := splitQuery(c.Query("excludeAllergens"))
+	if tag == "" && len(excludeAllergens) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"statuCode": http.StatusBadRequest,
-			"error":     "`tag` parameter is required.",
+			"statusCode": http.StatusBadRequest,
+			"error":      "`tag` or `excludeAllergens` parameter is required.",
 		})
+		return
+	}
+	known := handler.classifier.Allergens()
+	for _, allergen := range excludeAllergens {
+		if !slices.Contains(known, allergen) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": http.StatusBadRequest,
+				"error":      fmt.Sprintf("Unknown allergen %q, expected one of %s.", allergen, strings.Join(known, ", ")),
+			})
+			return
+		}
+	}
+	var order bson.D
+	fields, err := listFields(c)
+	if err == nil {
+		order, err = listSort(c)
 	}
-
-	query := make([]string, 0)
-	cursor, err := handler.Collection.Find(handler.Ctx, bson.M{
-		"tags": bson.M{
-			"$in": append(query, tag),
-		},
-	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": http.StatusInternalServerError,
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
 			"error":      err.Error(),
 		})
+		return
 	}
-	defer cursor.Close(handler.Ctx)
 
-	recipes := make([]models.Recipe, 0)
-	for cursor.Next(handler.Ctx) {
-		var recipe models.Recipe
-		cursor.Decode(&recipe)
-		recipes = append(recipes, recipe)
+	key := searchKey(tag, excludeAllergens, fields, order)
+	data, err := handler.cache.Fetch(ctx, key, handler.cache.TTL.Search, func(ctx context.Context) ([]byte, []string, error) {
+		// the page is invalidated by writes to the recipes it contains and
+		// by new recipes that could match it
+		invalidation := []string{tagSearch}
+		filter := bson.M{}
+		if tag != "" {
+			// aliases and child tags match too, e.g. "seafood" finds "shrimp"
+			tags, err := expandTag(ctx, handler.TagsCollection, tag)
+			if err != nil {
+				return nil, nil, err
+			}
+			filter["tags"] = bson.M{"$in": tags}
+			for _, t := range tags {
+				invalidation = append(invalidation, searchTag(t))
+			}
+		} else {
+			invalidation = append(invalidation, tagSearchAll)
+		}
+		if len(excludeAllergens) > 0 {
+			filter["allergens"] = bson.M{"$nin": excludeAllergens}
+		}
+
+		log.Println("Request to MongoDB")
+		recipes, err := handler.findRecipes(ctx, filter, fields, order)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, recipe := range recipes {
+			invalidation = append(invalidation, recipeTag(recipe.ID.Hex()))
+		}
+		data, err := handler.cache.Encode(project(recipes, fields))
+		return data, invalidation, err
+	})
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	writePayload(c, data)
+}
+
+// splitQuery turns a comma-separated query value into its non-empty parts.
+func splitQuery(value string) []string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
 	}
-	c.JSON(http.StatusOK, recipes)
+	return parts
 }
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	databases "github.com/wtlow003/recipe-gin-api/db"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthFailing  = "failing"
+	healthDraining = "draining"
+
+	dependencyUp   = "up"
+	dependencyDown = "down"
+)
+
+type HealthHandler struct {
+	mongo *databases.MongoDB
+	redis *databases.RedisCache
+	// timeout bounds each dependency check.
+	timeout time.Duration
+	// draining is set once the server is shutting down.
+	draining atomic.Bool
+}
+
+func NewHealthHandler(mongo *databases.MongoDB, redis *databases.RedisCache, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		mongo:   mongo,
+		redis:   redis,
+		timeout: timeout,
+	}
+}
+
+// dependency is a backing service checked for readiness.
+type dependency struct {
+	name string
+	// required dependencies fail readiness when down; optional ones
+	// only degrade it
+	required bool
+	ping     func(ctx context.Context) error
+	version  func(ctx context.Context) (string, error)
+}
+
+// Drain fails readiness from now on, ahead of shutting down.
+func (handler *HealthHandler) Drain() {
+	handler.draining.Store(true)
+}
+
+// Live reports that the process is up and serving requests. It does not
+// check dependencies, so an outage doesn't get every replica restarted.
+func (handler *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, models.Health{Status: healthOK})
+}
+
+// Ready checks every dependency and responds 503 when a required one is
+// down, so load balancers route around the replica. Redis is optional:
+// while it is down responses are served without the shared cache and
+// the status is degraded.
+func (handler *HealthHandler) Ready(c *gin.Context) {
+	if handler.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, models.Health{Status: healthDraining})
+		return
+	}
+
+	dependencies := []dependency{
+		{
+			name:     "mongodb",
+			required: true,
+			ping:     handler.mongo.Ping,
+			version:  handler.mongo.Version,
+		},
+		{
+			name: "redis",
+			ping: func(context.Context) error {
+				return handler.redis.Ping()
+			},
+			version: func(context.Context) (string, error) {
+				return handler.redis.Version()
+			},
+		},
+	}
+
+	health := models.Health{
+		Status:       healthOK,
+		Dependencies: make(map[string]models.DependencyHealth),
+	}
+	results := make([]models.DependencyHealth, len(dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+			results[i] = handler.check(c.Request.Context(), dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for i, dep := range dependencies {
+		health.Dependencies[dep.name] = results[i]
+		switch {
+		case results[i].Status == dependencyUp:
+		case dep.required:
+			health.Status = healthFailing
+			status = http.StatusServiceUnavailable
+		case health.Status == healthOK:
+			health.Status = healthDegraded
+		}
+	}
+	c.JSON(status, health)
+}
+
+// check pings a dependency and reads its version, giving up after the
+// handler's timeout.
+func (handler *HealthHandler) check(ctx context.Context, dep dependency) models.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, handler.timeout)
+	defer cancel()
+
+	result := models.DependencyHealth{Status: dependencyDown, Required: dep.required}
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		if err := dep.ping(ctx); err != nil {
+			done <- err
+			return
+		}
+		result.Latency = float64(time.Since(start).Microseconds()) / 1000
+		result.Version, _ = dep.version(ctx)
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			result.Latency = float64(time.Since(start).Microseconds()) / 1000
+			result.Error = err.Error()
+			return result
+		}
+		result.Status = dependencyUp
+		return result
+	case <-ctx.Done():
+		return models.DependencyHealth{
+			Status:   dependencyDown,
+			Required: dep.required,
+			Latency:  float64(time.Since(start).Microseconds()) / 1000,
+			Error:    ctx.Err().Error(),
+		}
+	}
+}
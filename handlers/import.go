@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+	"github.com/wtlow003/recipe-gin-api/schemaorg"
+)
+
+// maxImportSize caps the document read by ImportRecipe; larger ones are
+// rejected with 413.
+const maxImportSize = 5 << 20
+
+// ImportRecipe	godoc
+// @Summary		Import recipe
+// @Description	map a schema.org Recipe, sent as JSON-LD or embedded in an HTML page, onto a recipe. The document is parsed as uploaded and no URLs are fetched.
+// @Description	NDJSON and CSV bodies are bulk imports: every row is validated and upserted on its own and a models.ImportReport is returned instead.
+// @Tags		recipes
+// @Accept		json
+// @Accept		html
+// @Accept		application/x-ndjson
+// @Accept		text/csv
+// @Produce		json
+// @Param		X-User-ID	header	string	false	"Owner of created recipes"
+// @Param		X-User-Signature	header	string	false	"Hex HMAC-SHA256 of X-User-ID, signed by the gateway"
+// @Param		document	body	string	true	"schema.org Recipe JSON-LD, HTML page, NDJSON or CSV"
+// @Param		persist		query	bool	false	"Store the recipe, once it validates, instead of returning a preview"
+// @Param		dryRun		query	bool	false	"Bulk import: validate rows without writing"
+// @Param		strategy	query	string	false	"Bulk import: match existing recipes by id or name"	Enums(id, name)	default(id)
+// @Success		200 {object}	models.Recipe
+// @Failure		400	{object}	models.Error
+// @Failure		413	{object}	models.Error
+// @Failure		415	{object}	models.Error
+// @Failure		422	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/import	[post]
+func (handler *RecipesHandler) ImportRecipe(c *gin.Context) {
+	ctx := c.Request.Context()
+	switch c.ContentType() {
+	case mimeNDJSON, mimeCSV:
+		handler.importRecipes(c)
+		return
+	}
+
+	document, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"statusCode": http.StatusRequestEntityTooLarge,
+			"error":      fmt.Sprintf("Document must not exceed %d bytes.", maxImportSize),
+		})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	var recipe models.Recipe
+	switch c.ContentType() {
+	case "application/ld+json", "application/json":
+		recipe, err = schemaorg.RecipeFromJSONLD(document)
+	case "text/html":
+		recipe, err = schemaorg.RecipeFromHTML(document)
+	default:
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"statusCode": http.StatusUnsupportedMediaType,
+			"error":      "Content-Type must be application/ld+json, text/html, application/x-ndjson or text/csv.",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"statusCode": http.StatusUnprocessableEntity,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	handler.classify(&recipe)
+	if c.Query("persist") != "true" {
+		c.JSON(http.StatusOK, recipe)
+		return
+	}
+	if err := recipe.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	recipe.ID = primitive.NewObjectID()
+	recipe.PublishedAt = time.Now()
+	recipe.Owner = c.GetHeader(userHeader)
+	if _, err := handler.Collection.InsertOne(ctx, recipe); err != nil {
+		if timeoutError(c, err) {
+			return
+		}
+		log.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": http.StatusInternalServerError,
+			"error":      "Error inserting a new recipe!",
+		})
+		return
+	}
+
+	invalidateRecipes(handler.cache, nil, recipe.Tags)
+
+	c.JSON(http.StatusOK, recipe)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// maxLookup caps the IDs accepted by a single lookup.
+const maxLookup = 500
+
+const (
+	lookupFound    = "found"
+	lookupNotFound = "not_found"
+	lookupInvalid  = "invalid"
+)
+
+// LookupRecipes	godoc
+// @Summary		Look up recipes by ID
+// @Description	fetch many recipes at once, returned in request order with a status for IDs that are not found or invalid. Also available as GET /recipes?ids=a,b,c.
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		lookup	body	models.RecipeLookup	true	"Recipe IDs"
+// @Success		200 {array}		models.RecipeLookupResult
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/lookup	[post]
+func (handler *RecipesHandler) LookupRecipes(c *gin.Context) {
+	var lookup models.RecipeLookup
+	if err := c.ShouldBindJSON(&lookup); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+	handler.lookupRecipes(c, lookup.IDs)
+}
+
+func (handler *RecipesHandler) lookupRecipes(c *gin.Context, ids []string) {
+	ctx := c.Request.Context()
+	if len(ids) > maxLookup {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      fmt.Sprintf("At most %d IDs may be looked up at once.", maxLookup),
+		})
+		return
+	}
+
+	// canonical maps each requested ID to its lower-case hex form
+	canonical := make(map[string]string)
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		objectId, err := primitive.ObjectIDFromHex(id)
+		if _, ok := canonical[id]; err != nil || ok {
+			continue
+		}
+		canonical[id] = objectId.Hex()
+		valid = append(valid, objectId.Hex())
+	}
+
+	// read through the per-recipe cache, then fetch every miss at once
+	recipes := cachedRecipes(ctx, handler.cache, valid)
+	misses := make([]primitive.ObjectID, 0)
+	for _, id := range valid {
+		if _, ok := recipes[id]; !ok {
+			objectId, _ := primitive.ObjectIDFromHex(id)
+			misses = append(misses, objectId)
+		}
+	}
+	if len(misses) > 0 {
+		log.Println("Request to MongoDB")
+		since, clockErr := handler.cache.Clock(ctx)
+		cursor, err := handler.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": misses}})
+		if err != nil {
+			serverError(c, err)
+			return
+		}
+		fetched := make([]models.Recipe, 0, len(misses))
+		if err := cursor.All(ctx, &fetched); err != nil {
+			serverError(c, err)
+			return
+		}
+		for _, recipe := range fetched {
+			recipes[recipe.ID.Hex()] = recipe
+		}
+		if clockErr != nil {
+			log.Error(clockErr)
+		} else {
+			cacheRecipes(ctx, handler.cache, since, fetched)
+		}
+	}
+
+	results := make([]models.RecipeLookupResult, len(ids))
+	for i, id := range ids {
+		results[i] = models.RecipeLookupResult{ID: id, Status: lookupInvalid}
+		key, ok := canonical[id]
+		if !ok {
+			continue
+		}
+		if recipe, ok := recipes[key]; ok {
+			results[i].Status = lookupFound
+			results[i].Recipe = &recipe
+		} else {
+			results[i].Status = lookupNotFound
+		}
+	}
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/wtlow003/recipe-gin-api/ingredients"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+type PantriesHandler struct {
+	Collection        *mongo.Collection
+	RecipesCollection *mongo.Collection
+}
+
+func NewPantriesHandler(collection *mongo.Collection, recipesCollection *mongo.Collection) *PantriesHandler {
+	return &PantriesHandler{
+		Collection:        collection,
+		RecipesCollection: recipesCollection,
+	}
+}
+
+// NewPantry	godoc
+// @Summary		Create pantry
+// @Description	store the list of ingredients a user has at home
+// @Tags		pantries
+// @Accept		json
+// @Produce		json
+// @Param		pantry	body	models.UserDefinedPantry	true	"New pantry"
+// @Success		200 {object}	models.Pantry
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/pantries	[post]
+func (handler *PantriesHandler) NewPantry(c *gin.Context) {
+	ctx := c.Request.Context()
+	var request models.UserDefinedPantry
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	pantry := models.Pantry{
+		ID:        primitive.NewObjectID(),
+		Name:      request.Name,
+		Items:     request.Items,
+		UpdatedAt: time.Now(),
+	}
+	if _, err := handler.Collection.InsertOne(ctx, pantry); err != nil {
+		if timeoutError(c, err) {
+			return
+		}
+		log.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": http.StatusInternalServerError,
+			"error":      "Error inserting a new pantry!",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pantry)
+}
+
+// ListPantry	godoc
+// @Summary		List pantry
+// @Tags		pantries
+// @Accept		json
+// @Produce		json
+// @Param		id	path 		string	true 	"Pantry ID"
+// @Success		200 {object}	models.Pantry
+// @Failure		400 {object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/pantries/{id}	[get]
+func (handler *PantriesHandler) ListPantry(c *gin.Context) {
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	pantry, err := handler.findPantry(c.Request.Context(), objectId)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      err.Error(),
+		})
+		return
+	} else if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pantry)
+}
+
+// UpdatePantry	godoc
+// @Summary		Update pantry
+// @Tags		pantries
+// @Accept		json
+// @Produce		json
+// @Param		id		path 	string	true 	"Pantry ID"
+// @Param		pantry	body	models.UserDefinedPantry	true	"Updated pantry"
+// @Success		200 {object}	models.Message
+// @Failure		400	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/pantries/{id}	[put]
+func (handler *PantriesHandler) UpdatePantry(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+	var pantry models.UserDefinedPantry
+	if err := c.ShouldBindJSON(&pantry); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	res, err := handler.Collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectId},
+		bson.D{{
+			Key: "$set", Value: bson.D{
+				{Key: "name", Value: pantry.Name},
+				{Key: "items", Value: pantry.Items},
+				{Key: "updatedAt", Value: time.Now()},
+			},
+		}},
+	)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      "Pantry not found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Pantry has been updated!",
+	})
+}
+
+// CookableRecipes	godoc
+// @Summary		List cookable recipes
+// @Description	rank recipes by how few ingredients are missing from a pantry; recipes with no recognizable ingredients are left out
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		pantry	query 		string	true 	"Pantry ID"
+// @Param		limit	query 		int		false 	"Maximum number of recipes"	default(20)
+// @Success		200 {array}		models.CookableRecipe
+// @Failure		400	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500 {object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/cookable	[get]
+func (handler *PantriesHandler) CookableRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectId, err := primitive.ObjectIDFromHex(c.Query("pantry"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "`pantry` parameter must be a valid pantry ID.",
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "`limit` parameter must be a positive integer.",
+		})
+		return
+	}
+
+	pantry, err := handler.findPantry(c.Request.Context(), objectId)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      err.Error(),
+		})
+		return
+	} else if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	// rank on the ingredients alone, then load the recipes that made it
+	cursor, err := handler.RecipesCollection.Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"ingredients": 1}),
+	)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	cookable := make([]models.CookableRecipe, 0)
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			serverError(c, err)
+			return
+		}
+		missing, parsed := missingIngredients(recipe, pantry.Items)
+		if !parsed {
+			// nothing to match, so it would rank as needing nothing
+			continue
+		}
+		cookable = append(cookable, models.CookableRecipe{
+			Recipe:       recipe,
+			MissingCount: len(missing),
+			Missing:      missing,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		serverError(c, err)
+		return
+	}
+
+	sort.SliceStable(cookable, func(i, j int) bool {
+		return cookable[i].MissingCount < cookable[j].MissingCount
+	})
+	if len(cookable) > limit {
+		cookable = cookable[:limit]
+	}
+	if cookable, err = handler.loadCookable(ctx, cookable); err != nil {
+		serverError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cookable)
+}
+
+// loadCookable replaces the ranked recipes, which only hold their
+// ingredients, with the full recipes in a single query and keeps the
+// ranking. Recipes deleted in the meantime are dropped.
+func (handler *PantriesHandler) loadCookable(ctx context.Context, cookable []models.CookableRecipe) ([]models.CookableRecipe, error) {
+	ids := make([]primitive.ObjectID, len(cookable))
+	for i := range cookable {
+		ids[i] = cookable[i].Recipe.ID
+	}
+	cursor, err := handler.RecipesCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	recipes := make(map[primitive.ObjectID]models.Recipe, len(ids))
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			return nil, err
+		}
+		recipes[recipe.ID] = recipe
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	loaded := make([]models.CookableRecipe, 0, len(cookable))
+	for _, entry := range cookable {
+		if recipe, ok := recipes[entry.Recipe.ID]; ok {
+			entry.Recipe = recipe
+			loaded = append(loaded, entry)
+		}
+	}
+	return loaded, nil
+}
+
+func (handler *PantriesHandler) findPantry(ctx context.Context, id primitive.ObjectID) (models.Pantry, error) {
+	var pantry models.Pantry
+	err := handler.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&pantry)
+	return pantry, err
+}
+
+// missingIngredients lists the distinct normalized names of the recipe's
+// ingredients that no pantry item covers, and reports whether any of its
+// ingredients could be parsed at all.
+func missingIngredients(recipe models.Recipe, pantry []string) ([]string, bool) {
+	missing := make([]string, 0)
+	seen := make(map[string]bool)
+	parsed := false
+	for _, line := range recipe.Ingredients {
+		ing := ingredients.Parse(line)
+		if !ing.Valid() {
+			continue
+		}
+		parsed = true
+		found := false
+		for _, item := range pantry {
+			if ingredients.Matches(ing, item) {
+				found = true
+				break
+			}
+		}
+		if !found && !seen[ing.Name] {
+			seen[ing.Name] = true
+			missing = append(missing, ing.Name)
+		}
+	}
+	return missing, parsed
+}
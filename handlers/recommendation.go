@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/models"
+	"github.com/wtlow003/recipe-gin-api/recommend"
+)
+
+// maxSimilar is how many similar recipes are computed and cached per
+// recipe; the `limit` parameter slices this list.
+const maxSimilar = 50
+
+var errRecipeNotFound = errors.New("recipe not found")
+
+type RecommendationsHandler struct {
+	RecipesCollection *mongo.Collection
+	cache             *cache.Cache
+	index             *recommend.Index
+	ttl               time.Duration
+}
+
+func NewRecommendationsHandler(recipesCollection *mongo.Collection, cache *cache.Cache, index *recommend.Index, ttl time.Duration) *RecommendationsHandler {
+	return &RecommendationsHandler{
+		RecipesCollection: recipesCollection,
+		cache:             cache,
+		index:             index,
+		ttl:               ttl,
+	}
+}
+
+// SimilarRecipes	godoc
+// @Summary		List similar recipes
+// @Description	get the recipes most similar by ingredients, tags and nutrition; recipes created since the last index rebuild are scored on demand
+// @Tags		recipes
+// @Accept		json
+// @Produce		json
+// @Param		id		path 	string	true 	"Recipe ID"
+// @Param		limit	query 	int		false 	"Maximum number of recipes"	default(10)
+// @Success		200 {array}		models.SimilarRecipe
+// @Failure		400	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/recipes/{id}/similar	[get]
+func (handler *RecommendationsHandler) SimilarRecipes(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > maxSimilar {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "`limit` parameter must be between 1 and 50.",
+		})
+		return
+	}
+
+	data, err := handler.cache.Fetch(ctx, similarKey(id), handler.ttl, func(ctx context.Context) ([]byte, []string, error) {
+		matches, found := handler.index.Similar(objectId, maxSimilar)
+		if !found {
+			// created since the last rebuild
+			var recipe models.Recipe
+			err := handler.RecipesCollection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&recipe)
+			if err == mongo.ErrNoDocuments {
+				return nil, nil, errRecipeNotFound
+			} else if err != nil {
+				return nil, nil, err
+			}
+			matches = handler.index.SimilarTo(recipe, maxSimilar)
+		}
+		similar, err := handler.loadMatches(ctx, objectId, matches)
+		if err != nil {
+			return nil, nil, err
+		}
+		// any of the listed recipes changing invalidates the list
+		tags := []string{recipeTag(objectId.Hex())}
+		for _, match := range similar {
+			tags = append(tags, recipeTag(match.Recipe.ID.Hex()))
+		}
+		data, err := json.Marshal(similar)
+		return data, tags, err
+	})
+	if err == errRecipeNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      "Recipe not found.",
+		})
+		return
+	} else if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	similar := make([]models.SimilarRecipe, 0)
+	json.Unmarshal(data, &similar)
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	c.JSON(http.StatusOK, similar)
+}
+
+// loadMatches fetches the matched recipes in a single query and keeps
+// the index's ranking. Recipes deleted since the last rebuild are dropped,
+// and if the recipe id they were matched to was deleted it fails with
+// errRecipeNotFound.
+func (handler *RecommendationsHandler) loadMatches(ctx context.Context, id primitive.ObjectID, matches []recommend.Match) ([]models.SimilarRecipe, error) {
+	ids := make([]primitive.ObjectID, 0, len(matches)+1)
+	ids = append(ids, id)
+	for _, match := range matches {
+		ids = append(ids, match.ID)
+	}
+	cursor, err := handler.RecipesCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	recipes := make(map[primitive.ObjectID]models.Recipe)
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			return nil, err
+		}
+		recipes[recipe.ID] = recipe
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := recipes[id]; !ok {
+		return nil, errRecipeNotFound
+	}
+
+	similar := make([]models.SimilarRecipe, 0, len(matches))
+	for _, match := range matches {
+		if recipe, ok := recipes[match.ID]; ok {
+			similar = append(similar, models.SimilarRecipe{Recipe: recipe, Score: match.Score})
+		}
+	}
+	return similar, nil
+}
+
+// similarKey is the Redis key holding a recipe's similar recipes.
+func similarKey(id string) string {
+	return "recipes:" + id + ":similar"
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slices"
+
+	"github.com/wtlow003/recipe-gin-api/ingredients"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+type ShoppingListsHandler struct {
+	Collection        *mongo.Collection
+	RecipesCollection *mongo.Collection
+}
+
+func NewShoppingListsHandler(collection *mongo.Collection, recipesCollection *mongo.Collection) *ShoppingListsHandler {
+	return &ShoppingListsHandler{
+		Collection:        collection,
+		RecipesCollection: recipesCollection,
+	}
+}
+
+// NewShoppingList	godoc
+// @Summary		Create shopping list
+// @Description	consolidate the ingredients of recipes or a meal plan into a shopping list
+// @Tags		shopping-lists
+// @Accept		json
+// @Produce		json
+// @Param		request	body	models.ShoppingListRequest	true	"Recipes and servings"
+// @Success		200 {object}	models.ShoppingList
+// @Failure		400	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/shopping-lists	[post]
+func (handler *ShoppingListsHandler) NewShoppingList(c *gin.Context) {
+	ctx := c.Request.Context()
+	var request models.ShoppingListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	// a meal plan is just another source of recipes and servings
+	entries := request.Recipes
+	for _, meal := range request.MealPlan {
+		entries = append(entries, models.RecipeServings{
+			RecipeID: meal.RecipeID,
+			Servings: meal.Servings,
+		})
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      "At least one recipe or meal plan entry is required.",
+		})
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		objectId, err := primitive.ObjectIDFromHex(entry.RecipeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": http.StatusBadRequest,
+				"error":      fmt.Sprintf("Invalid recipe ID %q.", entry.RecipeID),
+			})
+			return
+		}
+		ids = append(ids, objectId)
+	}
+
+	cursor, err := handler.RecipesCollection.Find(ctx, bson.M{
+		"_id": bson.M{"$in": ids},
+	})
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	recipes := make(map[primitive.ObjectID]models.Recipe)
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		cursor.Decode(&recipe)
+		recipes[recipe.ID] = recipe
+	}
+	if err := cursor.Err(); err != nil {
+		serverError(c, err)
+		return
+	}
+
+	selected := make([]servedRecipe, 0, len(ids))
+	for i, id := range ids {
+		recipe, ok := recipes[id]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"statusCode": http.StatusNotFound,
+				"error":      fmt.Sprintf("Recipe %s not found.", id.Hex()),
+			})
+			return
+		}
+		selected = append(selected, servedRecipe{recipe: recipe, servings: entries[i].Servings})
+	}
+
+	list := models.ShoppingList{
+		ID:        primitive.NewObjectID(),
+		Name:      request.Name,
+		Aisles:    buildShoppingList(selected),
+		CreatedAt: time.Now(),
+	}
+	if _, err := handler.Collection.InsertOne(ctx, list); err != nil {
+		if timeoutError(c, err) {
+			return
+		}
+		log.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": http.StatusInternalServerError,
+			"error":      "Error inserting a new shopping list!",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// ListShoppingList	godoc
+// @Summary		List shopping list
+// @Tags		shopping-lists
+// @Accept		json
+// @Produce		json
+// @Param		id	path 		string	true 	"Shopping list ID"
+// @Success		200 {object}	models.ShoppingList
+// @Failure		400 {object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/shopping-lists/{id}	[get]
+func (handler *ShoppingListsHandler) ListShoppingList(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	var list models.ShoppingList
+	err = handler.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&list)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      err.Error(),
+		})
+		return
+	} else if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// UpdateShoppingListItem	godoc
+// @Summary		Check off shopping list item
+// @Tags		shopping-lists
+// @Accept		json
+// @Produce		json
+// @Param		id		path 	string	true 	"Shopping list ID"
+// @Param		itemId	path 	string	true 	"Item ID"
+// @Param		state	body	models.ShoppingListItemState	true	"Check-off state"
+// @Success		200 {object}	models.Message
+// @Failure		400	{object}	models.Error
+// @Failure		404	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/shopping-lists/{id}/items/{itemId}	[patch]
+func (handler *ShoppingListsHandler) UpdateShoppingListItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	objectId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+	itemId, err := primitive.ObjectIDFromHex(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+	var state models.ShoppingListItemState
+	if err := c.ShouldBindJSON(&state); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	res, err := handler.Collection.UpdateOne(
+		ctx,
+		bson.M{"_id": objectId, "aisles.items._id": itemId},
+		bson.M{"$set": bson.M{"aisles.$[].items.$[item].checked": state.Checked}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"item._id": itemId}},
+		}),
+	)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"statusCode": http.StatusNotFound,
+			"error":      "Shopping list item not found.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Shopping list item has been updated!",
+	})
+}
+
+type servedRecipe struct {
+	recipe   models.Recipe
+	servings int
+}
+
+// buildShoppingList scales each recipe to the requested servings, merges
+// identical ingredients across recipes and groups them by store aisle.
+func buildShoppingList(selected []servedRecipe) []models.ShoppingListAisle {
+	type group struct {
+		parts   []ingredients.Ingredient
+		recipes []primitive.ObjectID
+	}
+	groups := make(map[string]*group)
+	order := make([]string, 0)
+
+	for _, served := range selected {
+		factor := 1.0
+		if served.servings > 0 && served.recipe.Servings > 0 {
+			factor = float64(served.servings) / float64(served.recipe.Servings)
+		}
+		for _, line := range served.recipe.Ingredients {
+			ing := ingredients.Parse(line)
+			if !ing.Valid() {
+				continue
+			}
+			key := ing.Key()
+			g, ok := groups[key]
+			if !ok {
+				g = &group{}
+				groups[key] = g
+				order = append(order, key)
+			}
+			g.parts = append(g.parts, ing.Scale(factor))
+			if !slices.Contains(g.recipes, served.recipe.ID) {
+				g.recipes = append(g.recipes, served.recipe.ID)
+			}
+		}
+	}
+
+	byAisle := make(map[string][]models.ShoppingListItem)
+	for _, key := range order {
+		g := groups[key]
+		total := ingredients.Sum(g.parts...)
+		aisle := ingredients.AisleFor(total.Name)
+		byAisle[aisle] = append(byAisle[aisle], models.ShoppingListItem{
+			ID:       primitive.NewObjectID(),
+			Name:     total.Name,
+			Quantity: total.Quantity,
+			Unit:     total.Unit,
+			Recipes:  g.recipes,
+		})
+	}
+
+	aisles := make([]models.ShoppingListAisle, 0, len(byAisle))
+	names := make([]string, 0, len(ingredients.Aisles)+1)
+	for _, aisle := range ingredients.Aisles {
+		names = append(names, aisle.Name)
+	}
+	names = append(names, ingredients.OtherAisle)
+	for _, name := range names {
+		items, ok := byAisle[name]
+		if !ok {
+			continue
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		aisles = append(aisles, models.ShoppingListAisle{Name: name, Items: items})
+	}
+	return aisles
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/exp/slices"
+)
+
+// sortFields lists the recipe fields list endpoints can sort by. Each has
+// an index created at startup by SortIndexes.
+var sortFields = []string{
+	"publishedAt", "name", "calories", "fat", "satfat", "carbs",
+	"fiber", "sugar", "protein", "rating", "cookTime",
+}
+
+// listSort reads the `sort` parameter, e.g. "-publishedAt,name", into a
+// sort document. Ties are broken on _id so the order is stable for
+// cursor pagination; _id follows the direction of the first key so
+// single-key sorts are served by the indexes from SortIndexes.
+func listSort(c *gin.Context) (bson.D, error) {
+	return parseSort(c.Query("sort"))
+}
+
+// parseSort reads comma-separated sort keys.
+func parseSort(value string) (bson.D, error) {
+	order := bson.D{}
+	direction := 1
+	for i, key := range splitQuery(value) {
+		dir := 1
+		if strings.HasPrefix(key, "-") {
+			key, dir = key[1:], -1
+		} else {
+			key = strings.TrimPrefix(key, "+")
+		}
+		if !slices.Contains(sortFields, key) {
+			return nil, fmt.Errorf("cannot sort by %q, expected one of %s", key, strings.Join(sortFields, ", "))
+		}
+		field := recipeFields[key].bson
+		for _, e := range order {
+			if e.Key == field {
+				return nil, fmt.Errorf("%q appears more than once in `sort`", key)
+			}
+		}
+		if i == 0 {
+			direction = dir
+		}
+		order = append(order, bson.E{Key: field, Value: dir})
+	}
+	return append(order, bson.E{Key: "_id", Value: direction}), nil
+}
+
+// sortKey identifies a sort order in cache keys.
+func sortKey(order bson.D) string {
+	parts := make([]string, len(order))
+	for i, e := range order {
+		parts[i] = fmt.Sprintf("%s:%d", e.Key, e.Value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// SortIndexes are the indexes backing every single-field sort.
+func SortIndexes() []mongo.IndexModel {
+	indexes := make([]mongo.IndexModel, 0, len(sortFields))
+	for _, key := range sortFields {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys: bson.D{{Key: recipeFields[key].bson, Value: 1}, {Key: "_id", Value: 1}},
+		})
+	}
+	return indexes
+}
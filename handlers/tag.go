@@ -0,0 +1,496 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/exp/slices"
+
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+type TagsHandler struct {
+	Collection        *mongo.Collection
+	RecipesCollection *mongo.Collection
+	cache             *cache.Cache
+}
+
+func NewTagsHandler(collection *mongo.Collection, recipesCollection *mongo.Collection, cache *cache.Cache) *TagsHandler {
+	return &TagsHandler{
+		Collection:        collection,
+		RecipesCollection: recipesCollection,
+		cache:             cache,
+	}
+}
+
+// ListTags	godoc
+// @Summary		List tags
+// @Description	get every tag with the number of recipes using it
+// @Tags		tags
+// @Accept		json
+// @Produce		json
+// @Success		200	{array}		models.TagCount
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/tags [get]
+func (handler *TagsHandler) ListTags(c *gin.Context) {
+	data, err := handler.tagCounts(c.Request.Context())
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	writePayload(c, data)
+}
+
+// tagCounts returns the encoded tag counts, served from the cache.
+func (handler *TagsHandler) tagCounts(ctx context.Context) ([]byte, error) {
+	return handler.cache.Fetch(ctx, tagsKey, handler.cache.TTL.List, func(ctx context.Context) ([]byte, []string, error) {
+		log.Println("Request to MongoDB")
+		cursor, err := handler.RecipesCollection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$unwind", Value: "$tags"}},
+			{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$tags"},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}},
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		tags := make([]models.TagCount, 0)
+		if err := cursor.All(ctx, &tags); err != nil {
+			return nil, nil, err
+		}
+		data, err := handler.cache.Encode(tags)
+		return data, []string{tagRecipes}, err
+	})
+}
+
+// ListTaxonomy	godoc
+// @Summary		List tag taxonomy
+// @Description	get the aliases and parent of every managed tag
+// @Tags		tags
+// @Accept		json
+// @Produce		json
+// @Security	BasicAuth
+// @Success		200	{array}		models.Tag
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/admin/tags [get]
+func (handler *TagsHandler) ListTaxonomy(c *gin.Context) {
+	ctx := c.Request.Context()
+	taxonomy, err := loadTaxonomy(ctx, handler.Collection)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	tags := make([]models.Tag, 0, len(taxonomy))
+	for _, tag := range taxonomy {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	c.JSON(http.StatusOK, tags)
+}
+
+// UpdateTag	godoc
+// @Summary		Update tag taxonomy
+// @Description	set the aliases and parent of a tag, e.g. make "shrimp" a child of "seafood"
+// @Tags		tags
+// @Accept		json
+// @Produce		json
+// @Security	BasicAuth
+// @Param		name	path	string	true	"Tag name"
+// @Param		tag		body	models.UserDefinedTag	true	"Aliases and parent"
+// @Success		200	{object}	models.Tag
+// @Failure		400	{object}	models.Error
+// @Failure		409	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/admin/tags/{name} [put]
+func (handler *TagsHandler) UpdateTag(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+	var request models.UserDefinedTag
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	taxonomy, err := loadTaxonomy(ctx, handler.Collection)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	// walk up from the new parent to make sure the hierarchy stays a tree
+	visited := make(map[string]bool)
+	for parent := request.Parent; parent != ""; parent = taxonomy[parent].Parent {
+		if parent == name {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": http.StatusBadRequest,
+				"error":      fmt.Sprintf("Tag %q cannot be its own ancestor.", name),
+			})
+			return
+		}
+		if visited[parent] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": http.StatusBadRequest,
+				"error":      fmt.Sprintf("The ancestors of %q form a cycle at %q.", request.Parent, parent),
+			})
+			return
+		}
+		visited[parent] = true
+	}
+
+	tag := models.Tag{
+		Name:    name,
+		Aliases: make([]string, 0),
+		Parent:  request.Parent,
+	}
+	for _, alias := range request.Aliases {
+		if alias != name && !slices.Contains(tag.Aliases, alias) {
+			tag.Aliases = append(tag.Aliases, alias)
+		}
+	}
+	// an alias must resolve to exactly one tag
+	for _, alias := range tag.Aliases {
+		if other, ok := aliasOwner(taxonomy, alias, name); ok {
+			c.JSON(http.StatusConflict, gin.H{
+				"statusCode": http.StatusConflict,
+				"error":      fmt.Sprintf("%q is already the name or an alias of tag %q.", alias, other),
+			})
+			return
+		}
+	}
+	_, err = handler.Collection.ReplaceOne(ctx,
+		bson.M{"_id": name}, tag,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	// aliases and parents change how searches expand tags
+	log.Println("Remove data from Redis")
+	if err := handler.cache.Invalidate(tagSearch); err != nil {
+		log.Error(err)
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// RenameTag	godoc
+// @Summary		Rename tag
+// @Description	rename a tag on every recipe, keeping the old name as an alias
+// @Tags		tags
+// @Accept		json
+// @Produce		json
+// @Security	BasicAuth
+// @Param		rename	body	models.TagRename	true	"Old and new tag name"
+// @Success		200	{object}	models.Message
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/admin/tags/rename [post]
+func (handler *TagsHandler) RenameTag(c *gin.Context) {
+	var request models.TagRename
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	modified, err := handler.mergeTags(c.Request.Context(), []string{request.From}, request.To)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	format := "Renamed tag %q to %q on %d recipe!"
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf(format, request.From, request.To, modified),
+	})
+}
+
+// MergeTags	godoc
+// @Summary		Merge tags
+// @Description	replace several tags with a single target tag on every recipe
+// @Tags		tags
+// @Accept		json
+// @Produce		json
+// @Security	BasicAuth
+// @Param		merge	body	models.TagMerge	true	"Source tags and target tag"
+// @Success		200	{object}	models.Message
+// @Failure		400	{object}	models.Error
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/admin/tags/merge [post]
+func (handler *TagsHandler) MergeTags(c *gin.Context) {
+	var request models.TagMerge
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": http.StatusBadRequest,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	modified, err := handler.mergeTags(c.Request.Context(), request.Sources, request.Target)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	format := "Merged tags into %q on %d recipe!"
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf(format, request.Target, modified),
+	})
+}
+
+// DeleteTag	godoc
+// @Summary		Delete tag
+// @Description	remove a tag from every recipe and from the taxonomy
+// @Tags		tags
+// @Accept		json
+// @Produce		json
+// @Security	BasicAuth
+// @Param		name	path	string	true	"Tag name"
+// @Success		200	{object}	models.Message
+// @Failure		500	{object}	models.Error
+// @Failure		504	{object}	models.Error
+// @Router		/admin/tags/{name} [delete]
+func (handler *TagsHandler) DeleteTag(c *gin.Context) {
+	ctx := c.Request.Context()
+	name := c.Param("name")
+
+	affected, err := taggedRecipes(ctx, handler.RecipesCollection, []string{name})
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+	res, err := handler.RecipesCollection.UpdateMany(ctx,
+		bson.M{"tags": name},
+		bson.M{"$pull": bson.M{"tags": name}},
+	)
+	if err != nil {
+		serverError(c, err)
+		return
+	}
+
+	// children of the deleted tag move up to its parent
+	var tag models.Tag
+	err = handler.Collection.FindOneAndDelete(ctx, bson.M{"_id": name}).Decode(&tag)
+	if err != nil && err != mongo.ErrNoDocuments {
+		serverError(c, err)
+		return
+	}
+	update := bson.M{"$unset": bson.M{"parent": ""}}
+	if tag.Parent != "" {
+		update = bson.M{"$set": bson.M{"parent": tag.Parent}}
+	}
+	if _, err := handler.Collection.UpdateMany(ctx, bson.M{"parent": name}, update); err != nil {
+		serverError(c, err)
+		return
+	}
+
+	log.Println("Remove data from Redis")
+	if err := handler.cache.Invalidate(append(affected, tagRecipes, tagSearch)...); err != nil {
+		log.Error(err)
+	}
+
+	format := "Deleted tag %q from %d recipe!"
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf(format, name, res.ModifiedCount),
+	})
+}
+
+// mergeTags replaces the source tags with target on every recipe. The
+// replaced names become aliases of target so searches for them still work.
+func (handler *TagsHandler) mergeTags(ctx context.Context, sources []string, target string) (int64, error) {
+	replaced := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if source != target && !slices.Contains(replaced, source) {
+			replaced = append(replaced, source)
+		}
+	}
+	if len(replaced) == 0 {
+		return 0, nil
+	}
+
+	affected, err := taggedRecipes(ctx, handler.RecipesCollection, replaced)
+	if err != nil {
+		return 0, err
+	}
+	filter := bson.M{"tags": bson.M{"$in": replaced}}
+	res, err := handler.RecipesCollection.UpdateMany(ctx, filter,
+		bson.M{"$addToSet": bson.M{"tags": target}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	_, err = handler.RecipesCollection.UpdateMany(ctx, filter,
+		bson.M{"$pull": bson.M{"tags": bson.M{"$in": replaced}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// fold the replaced taxonomy entries into target
+	cursor, err := handler.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": replaced}})
+	if err != nil {
+		return 0, err
+	}
+	old := make([]models.Tag, 0)
+	if err := cursor.All(ctx, &old); err != nil {
+		return 0, err
+	}
+	aliases := append(make([]string, 0), replaced...)
+	parents := make(map[string]string, len(replaced))
+	for _, name := range replaced {
+		parents[name] = ""
+	}
+	for _, tag := range old {
+		aliases = append(aliases, tag.Aliases...)
+		parents[tag.Name] = tag.Parent
+	}
+	var current models.Tag
+	err = handler.Collection.FindOne(ctx, bson.M{"_id": target}).Decode(&current)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+	_, err = handler.Collection.UpdateOne(ctx,
+		bson.M{"_id": target},
+		bson.M{"$addToSet": bson.M{"aliases": bson.M{"$each": aliases}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := handler.Collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": replaced}}); err != nil {
+		return 0, err
+	}
+	// children of the replaced tags move under target, except target
+	// itself, which would become its own parent
+	_, err = handler.Collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$ne": target}, "parent": bson.M{"$in": replaced}},
+		bson.M{"$set": bson.M{"parent": target}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if parent := mergedParent(current.Parent, parents, target); parent != current.Parent {
+		_, err = handler.Collection.UpdateOne(ctx,
+			bson.M{"_id": target},
+			bson.M{"$set": bson.M{"parent": parent}},
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	log.Println("Remove data from Redis")
+	if err := handler.cache.Invalidate(append(affected, tagRecipes, tagSearch)...); err != nil {
+		log.Error(err)
+	}
+	return res.MatchedCount, nil
+}
+
+// mergedParent returns the parent target keeps once the tags in parents,
+// mapped to their own parents, are merged into it. When target was under
+// a replaced tag it moves up to the nearest ancestor that is not replaced.
+func mergedParent(parent string, parents map[string]string, target string) string {
+	visited := make(map[string]bool)
+	for {
+		grandparent, replaced := parents[parent]
+		if !replaced || visited[parent] {
+			break
+		}
+		visited[parent] = true
+		parent = grandparent
+	}
+	if _, replaced := parents[parent]; replaced || parent == target {
+		return ""
+	}
+	return parent
+}
+
+// aliasOwner returns the tag other than name that alias already names,
+// either as the tag itself or as one of its aliases.
+func aliasOwner(taxonomy map[string]models.Tag, alias string, name string) (string, bool) {
+	if _, ok := taxonomy[alias]; ok && alias != name {
+		return alias, true
+	}
+	for _, managed := range taxonomy {
+		if managed.Name != name && slices.Contains(managed.Aliases, alias) {
+			return managed.Name, true
+		}
+	}
+	return "", false
+}
+
+// loadTaxonomy returns every managed tag keyed by name.
+func loadTaxonomy(ctx context.Context, collection *mongo.Collection) (map[string]models.Tag, error) {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]models.Tag, 0)
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	taxonomy := make(map[string]models.Tag, len(tags))
+	for _, tag := range tags {
+		taxonomy[tag.Name] = tag
+	}
+	return taxonomy, nil
+}
+
+// expandTag resolves an alias to its tag and returns that tag together
+// with all of its descendants and their aliases, so searching "seafood"
+// also finds recipes tagged "shrimp" or "prawns".
+func expandTag(ctx context.Context, collection *mongo.Collection, tag string) ([]string, error) {
+	taxonomy, err := loadTaxonomy(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical := tag
+	if _, ok := taxonomy[tag]; !ok {
+		for _, managed := range taxonomy {
+			if slices.Contains(managed.Aliases, tag) {
+				canonical = managed.Name
+				break
+			}
+		}
+	}
+
+	expanded := []string{canonical}
+	for i := 0; i < len(expanded); i++ {
+		for _, managed := range taxonomy {
+			if managed.Parent == expanded[i] && !slices.Contains(expanded, managed.Name) {
+				expanded = append(expanded, managed.Name)
+			}
+		}
+	}
+	for _, name := range expanded {
+		expanded = append(expanded, taxonomy[name].Aliases...)
+	}
+	if !slices.Contains(expanded, tag) {
+		expanded = append(expanded, tag)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,72 @@
+package ingredients
+
+import "strings"
+
+// Aisle is a store section and the ingredient keywords shelved there.
+type Aisle struct {
+	Name     string
+	Keywords []string
+}
+
+const OtherAisle = "Other"
+
+// Aisles are checked in order, so more specific sections come first
+// ("almond milk" is matched by Nuts before Dairy sees "milk").
+var Aisles = []Aisle{
+	{Name: "Spices & Seasonings", Keywords: []string{
+		"salt", "pepper flake", "black pepper", "peppercorn", "paprika", "cumin", "cinnamon",
+		"nutmeg", "oregano", "thyme", "rosemary", "chili powder", "curry", "turmeric",
+		"clove", "cardamom", "bay leaf", "vanilla", "seasoning", "spice", "garlic powder",
+		"onion powder",
+	}},
+	{Name: "Nuts & Seeds", Keywords: []string{
+		"almond", "walnut", "pecan", "cashew", "pistachio", "peanut", "hazelnut",
+		"sesame", "chia", "flax", "pumpkin seed", "sunflower seed", "nut butter",
+	}},
+	{Name: "Meat & Seafood", Keywords: []string{
+		"chicken", "beef", "pork", "bacon", "sausage", "lamb", "turkey", "ham",
+		"shrimp", "salmon", "trout", "tuna", "cod", "fish", "crab", "scallop", "prosciutto",
+	}},
+	{Name: "Dairy & Eggs", Keywords: []string{
+		"milk", "cream", "butter", "cheese", "yogurt", "egg", "feta", "parmesan",
+		"mozzarella", "ricotta", "buttermilk", "ghee",
+	}},
+	{Name: "Baking", Keywords: []string{
+		"flour", "sugar", "baking powder", "baking soda", "yeast", "cornstarch",
+		"cocoa", "chocolate", "molasses", "honey", "syrup", "cream of tartar", "panko",
+		"breadcrumb",
+	}},
+	{Name: "Pantry", Keywords: []string{
+		"oil", "vinegar", "sauce", "broth", "stock", "rice", "pasta", "noodle", "bean",
+		"lentil", "oat", "quinoa", "tomato paste", "paste", "mustard", "mayonnaise", "ketchup",
+		"miso", "wine", "water",
+	}},
+	{Name: "Produce", Keywords: []string{
+		"onion", "garlic", "shallot", "tomato", "potato", "carrot", "celery", "lettuce",
+		"spinach", "kale", "cabbage", "pepper", "jalapeno", "lemon", "lime", "orange",
+		"apple", "banana", "strawberry", "blueberry", "raspberry", "avocado", "cucumber", "zucchini", "mushroom",
+		"ginger", "parsley", "cilantro", "basil", "mint", "chive", "scallion", "leek",
+		"broccoli", "cauliflower", "squash", "pea", "bean sprout", "herb", "fruit",
+		"plantain",
+	}},
+	{Name: "Bakery", Keywords: []string{"bread", "bun", "tortilla", "pita", "bagel"}},
+}
+
+// AisleFor returns the store section for a normalized ingredient name.
+func AisleFor(name string) string {
+	for _, aisle := range Aisles {
+		for _, keyword := range aisle.Keywords {
+			if ContainsPhrase(name, keyword) {
+				return aisle.Name
+			}
+		}
+	}
+	return OtherAisle
+}
+
+// ContainsPhrase reports whether phrase appears in name on word
+// boundaries, so "egg" matches "egg yolk" but not "eggplant".
+func ContainsPhrase(name, phrase string) bool {
+	name, phrase = " "+strings.ToLower(name)+" ", " "+strings.ToLower(phrase)+" "
+	return strings.Contains(name, phrase)
+}
@@ -0,0 +1,162 @@
+package ingredients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Category is a group of ingredients sharing a dietary property, such
+// as "dairy" or "meat". Allergen categories are reported on recipes.
+type Category struct {
+	Name     string   `json:"name"`
+	Allergen bool     `json:"allergen"`
+	Keywords []string `json:"keywords"`
+	// Exceptions are more specific names that look like a keyword but do
+	// not belong to the category, e.g. "almond milk" for dairy.
+	Exceptions []string `json:"exceptions"`
+}
+
+// Diet is satisfied by a recipe containing none of the excluded categories.
+type Diet struct {
+	Name     string   `json:"name"`
+	Excludes []string `json:"excludes"`
+}
+
+// Classifier derives allergens and diets from a recipe's ingredients.
+// Its rules are plain data so they can be extended without code changes.
+type Classifier struct {
+	Categories []Category `json:"categories"`
+	Diets      []Diet     `json:"diets"`
+	// Version identifies the rules, so recipes classified with other
+	// rules can be found and classified again.
+	Version string `json:"-"`
+}
+
+// LoadClassifier reads and validates classifier rules from a JSON file.
+func LoadClassifier(path string) (*Classifier, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var classifier Classifier
+	if err := json.Unmarshal(f, &classifier); err != nil {
+		return nil, err
+	}
+	if err := classifier.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sum := sha256.Sum256(f)
+	classifier.Version = hex.EncodeToString(sum[:8])
+	return &classifier, nil
+}
+
+// Validate checks that categories and diets are named once each, that
+// categories have keywords and that diets only exclude known categories.
+func (classifier *Classifier) Validate() error {
+	var errs []error
+	categories := make(map[string]bool)
+	for _, category := range classifier.Categories {
+		switch {
+		case strings.TrimSpace(category.Name) == "":
+			errs = append(errs, errors.New("category without a name"))
+		case categories[category.Name]:
+			errs = append(errs, fmt.Errorf("category %q is defined twice", category.Name))
+		case len(category.Keywords) == 0:
+			errs = append(errs, fmt.Errorf("category %q has no keywords", category.Name))
+		}
+		categories[category.Name] = true
+	}
+	diets := make(map[string]bool)
+	for _, diet := range classifier.Diets {
+		switch {
+		case strings.TrimSpace(diet.Name) == "":
+			errs = append(errs, errors.New("diet without a name"))
+		case diets[diet.Name]:
+			errs = append(errs, fmt.Errorf("diet %q is defined twice", diet.Name))
+		}
+		diets[diet.Name] = true
+		for _, excluded := range diet.Excludes {
+			if !categories[excluded] {
+				errs = append(errs, fmt.Errorf("diet %q excludes unknown category %q", diet.Name, excluded))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Allergens lists the names of the allergen categories.
+func (classifier *Classifier) Allergens() []string {
+	allergens := make([]string, 0)
+	for _, category := range classifier.Categories {
+		if category.Allergen {
+			allergens = append(allergens, category.Name)
+		}
+	}
+	return allergens
+}
+
+// Classify returns the allergens found in the ingredient lines and the
+// diets they satisfy, both sorted. Lines that name no ingredient are
+// skipped; if none do, no diet is claimed either.
+func (classifier *Classifier) Classify(lines []string) (allergens []string, diets []string) {
+	found := make(map[string]bool)
+	parsed := false
+	for _, line := range lines {
+		ing := Parse(line)
+		if !ing.Valid() {
+			continue
+		}
+		parsed = true
+		for _, category := range classifier.Categories {
+			if !found[category.Name] && category.matches(ing.Name) {
+				found[category.Name] = true
+			}
+		}
+	}
+
+	allergens = make([]string, 0)
+	for _, category := range classifier.Categories {
+		if category.Allergen && found[category.Name] {
+			allergens = append(allergens, category.Name)
+		}
+	}
+	diets = make([]string, 0)
+	if !parsed {
+		return allergens, diets
+	}
+	for _, diet := range classifier.Diets {
+		satisfied := true
+		for _, excluded := range diet.Excludes {
+			if found[excluded] {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			diets = append(diets, diet.Name)
+		}
+	}
+	sort.Strings(allergens)
+	sort.Strings(diets)
+	return allergens, diets
+}
+
+func (category Category) matches(name string) bool {
+	for _, exception := range category.Exceptions {
+		if ContainsPhrase(name, exception) {
+			return false
+		}
+	}
+	for _, keyword := range category.Keywords {
+		if ContainsPhrase(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
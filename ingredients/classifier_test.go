@@ -0,0 +1,68 @@
+package ingredients
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	classifier, err := LoadClassifier("../allergens.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := []string{"dairy-free", "gluten-free", "vegan", "vegetarian"}
+	for _, tc := range []struct {
+		name      string
+		lines     []string
+		allergens []string
+		diets     []string
+	}{
+		{"no ingredients", nil, []string{}, []string{}},
+		{"nothing parsed", []string{"", "  ", "<hr>", "2"}, []string{}, []string{}},
+		{"nothing found", []string{"2 carrots", "1 tbsp olive oil"}, []string{}, all},
+		{
+			"allergen",
+			[]string{"1 cup whole milk", "2 carrots"},
+			[]string{"dairy"},
+			[]string{"gluten-free", "vegetarian"},
+		},
+		{
+			"separator skipped",
+			[]string{"<hr>", "2 large eggs"},
+			[]string{"eggs"},
+			[]string{"dairy-free", "gluten-free", "vegetarian"},
+		},
+		{
+			"non-allergen category",
+			[]string{"1 lb chicken thighs"},
+			[]string{},
+			[]string{"dairy-free", "gluten-free"},
+		},
+		// "peanut butter" is a dairy exception, so only nuts match
+		{"exception", []string{"1/2 cup peanut butter"}, []string{"nuts"}, all},
+		// "almond flour" is a gluten exception
+		{"exception in another category", []string{"2 cups almond flour"}, []string{"nuts"}, all},
+		{
+			"overlapping categories",
+			[]string{"1 cup buttermilk", "1 cup flour"},
+			[]string{"dairy", "gluten"},
+			[]string{"vegetarian"},
+		},
+		{
+			"one line in two categories",
+			[]string{"9 oz cheese tortellini pasta"},
+			[]string{"dairy", "gluten"},
+			[]string{"vegetarian"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			allergens, diets := classifier.Classify(tc.lines)
+			if !reflect.DeepEqual(allergens, tc.allergens) {
+				t.Errorf("allergens = %q, want %q", allergens, tc.allergens)
+			}
+			if !reflect.DeepEqual(diets, tc.diets) {
+				t.Errorf("diets = %q, want %q", diets, tc.diets)
+			}
+		})
+	}
+}
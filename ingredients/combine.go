@@ -0,0 +1,54 @@
+package ingredients
+
+// Dimension reports what kind of amount the ingredient is measured in.
+// Lines without a unit are counted ("2 eggs").
+func (ing Ingredient) Dimension() Dimension {
+	if u, ok := unitByName(ing.Unit); ok {
+		return u.Dimension
+	}
+	return Count
+}
+
+// Key identifies ingredients that can be added together: same name and
+// convertible units. Counted units only merge with the same unit, so
+// "2 clove garlic" and "1 head garlic" stay apart.
+func (ing Ingredient) Key() string {
+	dimension := ing.Dimension()
+	if dimension == Count {
+		return ing.Name + "|" + string(dimension) + "|" + ing.Unit
+	}
+	return ing.Name + "|" + string(dimension)
+}
+
+// Scale multiplies the quantity, e.g. to adjust a recipe's servings.
+func (ing Ingredient) Scale(factor float64) Ingredient {
+	ing.Quantity = round(ing.Quantity * factor)
+	return ing
+}
+
+// Sum adds up ingredients sharing the same Key and expresses the total
+// in the most readable unit, so 2 tbsp + 1/4 cup becomes 6 tbsp.
+func Sum(items ...Ingredient) Ingredient {
+	if len(items) == 0 {
+		return Ingredient{}
+	}
+	total := Ingredient{Name: items[0].Name, Unit: items[0].Unit}
+	dimension := items[0].Dimension()
+	if dimension == Count {
+		for _, item := range items {
+			total.Quantity += item.Quantity
+		}
+		total.Quantity = round(total.Quantity)
+		return total
+	}
+
+	var base float64
+	metric := true
+	for _, item := range items {
+		u, _ := unitByName(item.Unit)
+		base += item.Quantity * u.Factor
+		metric = metric && u.Metric
+	}
+	total.Quantity, total.Unit = humanize(base, dimension, metric)
+	return total
+}
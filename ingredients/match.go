@@ -0,0 +1,13 @@
+package ingredients
+
+// Matches reports whether a pantry item covers a recipe ingredient. The
+// normalized pantry item must appear in the ingredient's name: "rosemary"
+// covers "1 teaspoon rosemary, minced", but "chicken stock" doesn't cover
+// "1 whole chicken".
+func Matches(ingredient Ingredient, pantryItem string) bool {
+	item := NormalizeName(pantryItem)
+	if item == "" || ingredient.Name == "" {
+		return false
+	}
+	return ContainsPhrase(ingredient.Name, item)
+}
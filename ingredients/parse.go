@@ -0,0 +1,232 @@
+package ingredients
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Ingredient is a single line of a recipe's ingredient list broken
+// down into quantity, unit and a normalized name.
+type Ingredient struct {
+	Raw      string
+	Quantity float64
+	Unit     string
+	Name     string
+	Note     string
+}
+
+var vulgarFractions = map[rune]float64{
+	'¼': 0.25, '½': 0.5, '¾': 0.75,
+	'⅓': 1.0 / 3, '⅔': 2.0 / 3,
+	'⅛': 0.125, '⅜': 0.375, '⅝': 0.625, '⅞': 0.875,
+}
+
+// words describing size, freshness or preparation rather than what to buy
+var descriptors = map[string]bool{
+	"large": true, "extra-large": true, "medium": true, "small": true, "jumbo": true,
+	"fresh": true, "freshly": true, "chopped": true, "minced": true, "diced": true,
+	"sliced": true, "grated": true, "shredded": true, "peeled": true, "softened": true,
+	"melted": true, "beaten": true, "finely": true, "roughly": true, "thinly": true,
+	"coarsely": true, "optional": true, "heaping": true, "packed": true,
+}
+
+// words that end in "s" but are not plurals
+var singularExceptions = map[string]bool{
+	"asparagus": true, "couscous": true, "hummus": true, "molasses": true,
+	"swiss": true, "grits": true, "citrus": true, "bass": true, "watercress": true,
+}
+
+// Parse splits a free-text ingredient such as "1 1/2 cup heavy cream, cold"
+// into its parts. Lines without a leading quantity get a zero Quantity.
+func Parse(line string) Ingredient {
+	ing := Ingredient{Raw: strings.TrimSpace(line)}
+	text := ing.Raw
+
+	// prep notes follow the first comma, e.g. "rosemary, minced"
+	if i := strings.Index(text, ","); i >= 0 {
+		ing.Note = strings.TrimSpace(text[i+1:])
+		text = text[:i]
+	}
+	text = stripParentheses(text)
+
+	tokens := strings.Fields(text)
+	ing.Quantity, tokens = parseQuantity(tokens)
+
+	// skip sizes such as "14-ounce" in "1 14-ounce can tomatoes"
+	// or "small" in "1/2 small clove garlic"
+	for len(tokens) > 0 && (strings.IndexFunc(tokens[0], unicode.IsDigit) >= 0 || descriptors[strings.ToLower(tokens[0])]) {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 {
+		if u, ok := lookupUnit(tokens[0]); ok {
+			ing.Unit = u.Name
+			tokens = tokens[1:]
+		}
+	}
+	// "fl oz" spans two tokens
+	if ing.Unit == "" && len(tokens) > 1 && strings.EqualFold(strings.Trim(tokens[0], "."), "fl") {
+		if u, ok := lookupUnit(tokens[1]); ok && u.Name == "oz" {
+			ing.Unit = "fl oz"
+			tokens = tokens[2:]
+		}
+	}
+	// "1 garlic clove" reads the unit after the name
+	if ing.Unit == "" && len(tokens) > 1 {
+		if u, ok := lookupUnit(tokens[len(tokens)-1]); ok && u.Dimension == Count {
+			ing.Unit = u.Name
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+
+	ing.Name = NormalizeName(strings.Join(tokens, " "))
+	return ing
+}
+
+// Valid reports whether the line names something to buy. Seed data
+// uses "<hr>" lines as section separators.
+func (ing Ingredient) Valid() bool {
+	return ing.Name != "" && !strings.HasPrefix(ing.Raw, "<")
+}
+
+// NormalizeName lowercases an ingredient name and drops descriptors,
+// digits and plurals so that "Fresh Tomatoes" and "tomato" compare equal.
+func NormalizeName(name string) string {
+	name = stripParentheses(strings.ToLower(name))
+	words := make([]string, 0)
+	for _, word := range strings.Fields(name) {
+		word = strings.Trim(word, ".,;:!?*\"'-")
+		if word == "" || descriptors[word] || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = Singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// Singular returns a best-effort singular form of an English noun.
+func Singular(word string) string {
+	switch {
+	case singularExceptions[word]:
+		return word
+	case strings.HasSuffix(word, "leaves"):
+		return strings.TrimSuffix(word, "ves") + "f"
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"),
+		strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"),
+		strings.HasSuffix(word, "us"),
+		strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s") && len(word) > 2:
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// parseQuantity consumes leading numeric tokens ("1", "1/2", "1 1/2",
+// "1½", "2-3") and returns their sum with the remaining tokens.
+func parseQuantity(tokens []string) (float64, []string) {
+	var total float64
+	consumed := 0
+	for consumed < len(tokens) && consumed < 2 {
+		value, ok := parseNumber(tokens[consumed])
+		// only a fraction may follow a whole number ("1 1/2", not "1 14")
+		if !ok || (consumed == 1 && value >= 1) {
+			break
+		}
+		total += value
+		consumed++
+	}
+	return total, tokens[consumed:]
+}
+
+func parseNumber(token string) (float64, bool) {
+	// ranges such as "2-3" use the lower bound
+	if i := strings.Index(token, "-"); i > 0 {
+		token = token[:i]
+	}
+	if v, ok := parseDecimal(token); ok {
+		return v, true
+	}
+	if num, den, found := strings.Cut(token, "/"); found {
+		n, ok1 := parseDecimal(num)
+		d, ok2 := parseDecimal(den)
+		// a tiny denominator can still overflow
+		if ok1 && ok2 && d != 0 && !math.IsInf(n/d, 0) {
+			return n / d, true
+		}
+		return 0, false
+	}
+	// vulgar fractions, optionally after a whole number as in "1½"
+	runes := []rune(token)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	frac, ok := vulgarFractions[runes[len(runes)-1]]
+	if !ok {
+		return 0, false
+	}
+	if len(runes) == 1 {
+		return frac, true
+	}
+	whole, ok := parseDecimal(string(runes[:len(runes)-1]))
+	if !ok {
+		return 0, false
+	}
+	return whole + frac, true
+}
+
+// parseDecimal parses plain decimals such as "2" or "0.5". Unlike
+// strconv.ParseFloat it rejects signs, exponents, "nan" and "inf", which
+// are never quantities, and values out of range; NaN and infinities
+// can't be encoded as JSON.
+func parseDecimal(s string) (float64, bool) {
+	digits := 0
+	point := false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '.' && !point:
+			point = true
+		default:
+			return 0, false
+		}
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+func stripParentheses(text string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range text {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,79 @@
+package ingredients
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		quantity float64
+		unit     string
+		name     string
+	}{
+		{"2 eggs", 2, "", "egg"},
+		{"0.5 cup milk", 0.5, "cup", "milk"},
+		{"1/2 cup sugar", 0.5, "cup", "sugar"},
+		{"½ tsp salt", 0.5, "tsp", "salt"},
+		{"1 1/2 cups heavy cream, cold", 1.5, "cup", "heavy cream"},
+		{"1½ cups flour", 1.5, "cup", "flour"},
+		{"1 ½ cups flour", 1.5, "cup", "flour"},
+		// only a fraction may follow a whole number
+		{"1 14-ounce can tomatoes", 1, "can", "tomato"},
+		// ranges use the lower bound
+		{"2-3 tbsp olive oil", 2, "tbsp", "olive oil"},
+		{"1/2-1 tsp chili flakes", 0.5, "tsp", "chili flake"},
+		{"2 garlic cloves, minced", 2, "clove", "garlic"},
+		{"4 fl oz orange juice", 4, "fl oz", "orange juice"},
+		{"salt to taste", 0, "", "salt to taste"},
+		// exponents, hex, signs, NaN and infinities aren't quantities;
+		// tokens with digits are then skipped like sizes
+		{"1e3 g flour", 0, "g", "flour"},
+		{"0x10 g flour", 0, "g", "flour"},
+		{"NaN cups flour", 0, "", "nan cups flour"},
+		{"Inf cups flour", 0, "", "inf cups flour"},
+		{"+2 eggs", 0, "", "egg"},
+		{"1/0 cup water", 0, "cup", "water"},
+		{"1/1e-400 cup water", 0, "cup", "water"},
+	} {
+		ing := Parse(tc.line)
+		if ing.Quantity != tc.quantity || ing.Unit != tc.unit || ing.Name != tc.name {
+			t.Errorf("Parse(%q) = %v %q %q, want %v %q %q",
+				tc.line, ing.Quantity, ing.Unit, ing.Name, tc.quantity, tc.unit, tc.name)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	parse := func(lines ...string) []Ingredient {
+		items := make([]Ingredient, len(lines))
+		for i, line := range lines {
+			items[i] = Parse(line)
+		}
+		return items
+	}
+	for _, tc := range []struct {
+		lines    []string
+		quantity float64
+		unit     string
+	}{
+		{[]string{"2 tbsp butter", "1/4 cup butter"}, 6, "tbsp"},
+		{[]string{"1 cup milk", "1 cup milk"}, 2, "cup"},
+		{[]string{"8 oz flour", "8 oz flour"}, 1, "lb"},
+		{[]string{"500 g flour", "500 g flour"}, 1, "kg"},
+		{[]string{"250 ml stock", "0.5 l stock"}, 750, "ml"},
+		// mixing metric and imperial units totals in imperial ones
+		{[]string{"1 cup milk", "100 ml milk"}, 1.42, "cup"},
+		{[]string{"2 eggs", "3 eggs"}, 5, ""},
+		{[]string{"2 garlic cloves", "1 clove garlic"}, 3, "clove"},
+		// rounded before picking a unit: 3 tsp is a hair under 1 tbsp
+		{[]string{"3 tsp sugar"}, 1, "tbsp"},
+		{[]string{"1/3 cup milk", "1/3 cup milk", "1/3 cup milk"}, 1, "cup"},
+		{[]string{"1 tsp salt", "1 tsp salt"}, 2, "tsp"},
+		// the smallest unit is used even below 1
+		{[]string{"1/8 tsp salt"}, 0.13, "tsp"},
+	} {
+		total := Sum(parse(tc.lines...)...)
+		if total.Quantity != tc.quantity || total.Unit != tc.unit {
+			t.Errorf("Sum(%q) = %v %q, want %v %q", tc.lines, total.Quantity, total.Unit, tc.quantity, tc.unit)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ingredients
+
+import "strings"
+
+// Dimension groups units that can be converted into each other.
+type Dimension string
+
+const (
+	Volume Dimension = "volume"
+	Mass   Dimension = "mass"
+	Count  Dimension = "count"
+)
+
+type unit struct {
+	Name      string
+	Dimension Dimension
+	// factor converts one of this unit into the dimension's base unit
+	// (ml for volume, g for mass).
+	Factor float64
+	Metric bool
+}
+
+var units = []unit{
+	{Name: "tsp", Dimension: Volume, Factor: 4.92892},
+	{Name: "tbsp", Dimension: Volume, Factor: 14.7868},
+	{Name: "fl oz", Dimension: Volume, Factor: 29.5735},
+	{Name: "cup", Dimension: Volume, Factor: 236.588},
+	{Name: "pint", Dimension: Volume, Factor: 473.176},
+	{Name: "quart", Dimension: Volume, Factor: 946.353},
+	{Name: "gallon", Dimension: Volume, Factor: 3785.41},
+	{Name: "ml", Dimension: Volume, Factor: 1, Metric: true},
+	{Name: "l", Dimension: Volume, Factor: 1000, Metric: true},
+	{Name: "oz", Dimension: Mass, Factor: 28.3495},
+	{Name: "lb", Dimension: Mass, Factor: 453.592},
+	{Name: "g", Dimension: Mass, Factor: 1, Metric: true},
+	{Name: "kg", Dimension: Mass, Factor: 1000, Metric: true},
+	{Name: "clove", Dimension: Count, Factor: 1},
+	{Name: "can", Dimension: Count, Factor: 1},
+	{Name: "package", Dimension: Count, Factor: 1},
+	{Name: "piece", Dimension: Count, Factor: 1},
+	{Name: "slice", Dimension: Count, Factor: 1},
+	{Name: "stick", Dimension: Count, Factor: 1},
+	{Name: "bunch", Dimension: Count, Factor: 1},
+	{Name: "sprig", Dimension: Count, Factor: 1},
+	{Name: "pinch", Dimension: Count, Factor: 1},
+	{Name: "dash", Dimension: Count, Factor: 1},
+	{Name: "head", Dimension: Count, Factor: 1},
+	{Name: "stalk", Dimension: Count, Factor: 1},
+}
+
+// spellings found in recipe text mapped onto the canonical unit name
+var unitAliases = map[string]string{
+	"t": "tsp", "tsp": "tsp", "tsps": "tsp", "teaspoon": "tsp", "teaspoons": "tsp",
+	"tbs": "tbsp", "tbsp": "tbsp", "tbsps": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp",
+	"c": "cup", "cup": "cup", "cups": "cup",
+	"pint": "pint", "pints": "pint", "pt": "pint",
+	"quart": "quart", "quarts": "quart", "qt": "quart",
+	"gallon": "gallon", "gallons": "gallon", "gal": "gallon",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml",
+	"l": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+	"g": "g", "gram": "g", "grams": "g", "gr": "g",
+	"kg": "kg", "kilogram": "kg", "kilograms": "kg",
+	"clove": "clove", "cloves": "clove",
+	"can": "can", "cans": "can",
+	"package": "package", "packages": "package", "pkg": "package",
+	"piece": "piece", "pieces": "piece",
+	"slice": "slice", "slices": "slice",
+	"stick": "stick", "sticks": "stick",
+	"bunch": "bunch", "bunches": "bunch",
+	"sprig": "sprig", "sprigs": "sprig",
+	"pinch": "pinch", "pinches": "pinch",
+	"dash": "dash", "dashes": "dash",
+	"head": "head", "heads": "head",
+	"stalk": "stalk", "stalks": "stalk",
+}
+
+// lookupUnit resolves a token such as "Tbsp." or "ounces" to its unit.
+func lookupUnit(token string) (unit, bool) {
+	token = strings.Trim(token, ".,-")
+	// a capital "T" is the usual shorthand for tablespoon
+	if token == "T" {
+		token = "tbsp"
+	}
+	name, ok := unitAliases[strings.ToLower(token)]
+	if !ok {
+		return unit{}, false
+	}
+	return unitByName(name)
+}
+
+func unitByName(name string) (unit, bool) {
+	for _, u := range units {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return unit{}, false
+}
+
+// display units for each dimension, largest first
+var (
+	imperialVolume = []string{"cup", "tbsp", "tsp"}
+	metricVolume   = []string{"l", "ml"}
+	imperialMass   = []string{"lb", "oz"}
+	metricMass     = []string{"kg", "g"}
+)
+
+// humanize picks the largest display unit that keeps the quantity >= 1
+// once rounded, so 3 tsp, a hair under a tablespoon, reads as 1 tbsp.
+func humanize(base float64, dimension Dimension, metric bool) (float64, string) {
+	var candidates []string
+	switch {
+	case dimension == Volume && metric:
+		candidates = metricVolume
+	case dimension == Volume:
+		candidates = imperialVolume
+	case dimension == Mass && metric:
+		candidates = metricMass
+	default:
+		candidates = imperialMass
+	}
+	for i, name := range candidates {
+		u, _ := unitByName(name)
+		if qty := round(base / u.Factor); qty >= 1 || i == len(candidates)-1 {
+			return qty, name
+		}
+	}
+	return round(base), ""
+}
@@ -3,8 +3,15 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -18,16 +25,37 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/exp/slices"
 
+	"github.com/wtlow003/recipe-gin-api/cache"
+	"github.com/wtlow003/recipe-gin-api/config"
 	databases "github.com/wtlow003/recipe-gin-api/db"
 	_ "github.com/wtlow003/recipe-gin-api/docs"
 	"github.com/wtlow003/recipe-gin-api/handlers"
+	"github.com/wtlow003/recipe-gin-api/ingredients"
+	"github.com/wtlow003/recipe-gin-api/middlewares"
 	"github.com/wtlow003/recipe-gin-api/models"
+	"github.com/wtlow003/recipe-gin-api/recommend"
+	"github.com/wtlow003/recipe-gin-api/server"
 )
 
 var recipes []models.Recipe
 var ctx context.Context
+
+// conf is the configuration loaded in `setup()`.
+var conf *config.Config
+
+// stopBackground cancels the background work started in `setup()` on
+// shutdown.
+var stopBackground context.CancelFunc
+var mongoDB *databases.MongoDB
+var redisCache *databases.RedisCache
 var collection *mongo.Collection
 var recipesHandler *handlers.RecipesHandler
+var shoppingListsHandler *handlers.ShoppingListsHandler
+var pantriesHandler *handlers.PantriesHandler
+var tagsHandler *handlers.TagsHandler
+var recommendationsHandler *handlers.RecommendationsHandler
+var healthHandler *handlers.HealthHandler
+var cacheHandler *handlers.CacheHandler
 
 // prometheus setup
 var totalRequests = prometheus.NewCounterVec(
@@ -53,31 +81,48 @@ var httpDuration = promauto.NewHistogramVec(
 	[]string{"path"},
 )
 
-func init() {
-	// load env variable
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading from .env file!")
-	}
+// setup loads the configuration, connects to the databases and builds
+// the handlers. It is called from `main()` rather than `init()` so tests
+// in this package don't parse the test binary's flags or need databases.
+func setup() {
+	log.SetFormatter(&log.JSONFormatter{})
 
-	// setup logrus
-	logLevel, err := log.ParseLevel(os.Getenv("LOG_LEVEL"))
+	// a .env file is optional, e.g. containers get the environment injected
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading from .env file, err = %s", err)
+	}
+	var err error
+	conf, err = config.Load(os.Args[0], os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		logLevel = log.InfoLevel
+		log.Fatalf("Invalid configuration, err = %s", err)
 	}
 
+	// setup logrus; the level was validated with the configuration
+	logLevel, _ := log.ParseLevel(conf.LogLevel)
 	log.SetLevel(logLevel)
-	log.SetFormatter(&log.JSONFormatter{})
+	log.WithFields(conf.Redacted()).Info("Loaded configuration")
 
-	// setup mongodb connections
+	// setup mongodb connections, waiting for the databases to come up for
+	// at most STARTUP_TIMEOUT
 	ctx = context.Background()
-	mongoDB, err := databases.ConnectToMongoDB(
-		ctx,
-		os.Getenv("MONGO_INITDB_ROOT_USERNAME"),
-		os.Getenv("MONGO_INITDB_ROOT_PASSWORD"),
-		os.Getenv("MONGODB_HOSTNAME"),
-		os.Getenv("MONGODB_DATABASE"),
-		os.Getenv("MONGODB_PORT"),
+	startup, cancelStartup := context.WithTimeout(ctx, conf.Startup.Timeout)
+	defer cancelStartup()
+	retry := databases.Retry{
+		Initial: conf.Startup.RetryInitial,
+		Max:     conf.Startup.RetryMax,
+		Timeout: conf.Startup.AttemptTimeout,
+	}
+	mongoDB, err = databases.ConnectToMongoDB(
+		startup,
+		retry,
+		conf.MongoDB.Username,
+		conf.MongoDB.Password,
+		conf.MongoDB.Hostname,
+		conf.MongoDB.Database,
+		strconv.Itoa(conf.MongoDB.Port),
 	)
 	if err != nil {
 		log.Fatal(err.Error())
@@ -95,13 +140,24 @@ func init() {
 		os.Exit(1)
 	}
 
-	database := mongoDB.Client.Database(os.Getenv("MONGODB_DATABASE"))
+	// allergen and diet rules are data so they can be extended without a rebuild
+	classifier, err := ingredients.LoadClassifier("allergens.json")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	for i := range recipes {
+		recipes[i].Allergens, recipes[i].Diets = classifier.Classify(recipes[i].Ingredients)
+		recipes[i].ClassifiedWith = classifier.Version
+	}
+
+	database := mongoDB.Client.Database(conf.MongoDB.Database)
 	collections, err := database.ListCollectionNames(ctx, bson.D{})
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 	// check if collections already exist, else add data from
 	// recipes.json
+	reclassified := 0
 	if !slices.Contains(collections, "recipes") {
 		// Storing recipes into database
 		// Generic type required in `collection.InsertMany()`
@@ -118,27 +174,132 @@ func init() {
 	} else {
 		collection = database.Collection("recipes")
 		log.Info("Collection `recipe` already exists! No data is inserted.")
+		reclassified, err = classifyRecipes(collection, classifier)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
 	}
 
-	// Connect to redis
-	redis, err := databases.ConnectToRedis(
-		ctx,
-		os.Getenv("REDIS_PASSWORD"),
-		os.Getenv("REDIS_HOST"),
-		os.Getenv("REDIS_PORT"),
-	)
-	if err != nil {
+	// indexes backing the sort orders accepted by list endpoints and the
+	// fork listing
+	indexes := append(handlers.SortIndexes(), handlers.ForkIndexes()...)
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
 		log.Fatal(err.Error())
 	}
 
-	recipesHandler = handlers.NewRecipesHandler(ctx, collection, redis.Client)
+	// Connect to redis. It is optional, so startup only waits for a few
+	// attempts before going on without it.
+	redisRetry := retry
+	redisRetry.Attempts = conf.Redis.ConnectAttempts
+	var redisErr error
+	redisCache, redisErr = databases.ConnectToRedis(
+		startup,
+		redisRetry,
+		conf.Redis.Password,
+		conf.Redis.Host,
+		strconv.Itoa(conf.Redis.Port),
+		conf.Redis.ReadTimeout,
+		conf.Redis.WriteTimeout,
+	)
+
+	responseCache := cache.New(redisCache.Client, cache.Options{
+		TTL: cache.TTL{
+			Recipe: conf.Cache.RecipeTTL,
+			List:   conf.Cache.ListTTL,
+			Search: conf.Cache.SearchTTL,
+			Stale:  conf.Cache.StaleWindow,
+			Local:  conf.Cache.LocalTTL,
+		},
+		LocalBytes: conf.Cache.LocalMaxMB << 20,
+		Compress:   conf.Cache.Compress,
+		// Redis is optional; after repeated failures it is bypassed
+		// until a background probe reaches it again
+		FailureThreshold: conf.Cache.FailureThreshold,
+		ProbeInterval:    conf.Cache.ProbeInterval,
+	})
+	if redisErr != nil {
+		log.Warnf("Starting without Redis, err = %s", redisErr)
+		responseCache.Trip()
+	}
+	// entries cached by any replica still carry the old classification
+	if reclassified > 0 {
+		if _, err := responseCache.Flush(""); err != nil {
+			log.Warnf("Failed to flush the cache after reclassifying recipes, err = %s", err)
+		}
+	}
+	var background context.Context
+	background, stopBackground = context.WithCancel(ctx)
+	go responseCache.Subscribe(background)
+
+	tagsCollection := database.Collection("tags")
+	recipesHandler = handlers.NewRecipesHandler(collection, tagsCollection, responseCache, classifier)
+	tagsHandler = handlers.NewTagsHandler(tagsCollection, collection, responseCache)
+
+	// similarity index is rebuilt in the background, cached results expire
+	// with each rebuild
+	rebuildInterval := conf.Similarity.RebuildInterval
+	similarityIndex := recommend.NewIndex()
+	if err := similarityIndex.Rebuild(ctx, collection); err != nil {
+		log.Error(err)
+	}
+	go similarityIndex.RebuildEvery(background, collection, rebuildInterval)
+	recommendationsHandler = handlers.NewRecommendationsHandler(collection, responseCache, similarityIndex, rebuildInterval)
+	shoppingListsHandler = handlers.NewShoppingListsHandler(database.Collection("shopping_lists"), collection)
+	pantriesHandler = handlers.NewPantriesHandler(database.Collection("pantries"), collection)
+	healthHandler = handlers.NewHealthHandler(mongoDB, redisCache, conf.Health.ReadinessTimeout)
+
+	// warm the cache once seeded, so a new replica's first requests
+	// don't all go to MongoDB
+	cacheHandler = handlers.NewCacheHandler(responseCache, recipesHandler, tagsHandler, conf.Cache.WarmupTop)
+	if conf.Cache.Warmup {
+		cacheHandler.Warm(ctx, conf.Cache.WarmupTop)
+	}
 
 	prometheus.Register(totalRequests)
 	prometheus.Register(totalHTTPMethods)
 	prometheus.Register(httpDuration)
+	for _, collector := range cache.Collectors() {
+		prometheus.Register(collector)
+	}
 
 }
 
+// classifyRecipes derives allergens and diets again on recipes stored
+// before the classifier existed or classified with other rules, and
+// returns how many were updated.
+func classifyRecipes(collection *mongo.Collection, classifier *ingredients.Classifier) (int, error) {
+	cursor, err := collection.Find(ctx, bson.M{"classifiedWith": bson.M{"$ne": classifier.Version}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var recipe models.Recipe
+		if err := cursor.Decode(&recipe); err != nil {
+			return updated, err
+		}
+		allergens, diets := classifier.Classify(recipe.Ingredients)
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"_id": recipe.ID},
+			bson.M{"$set": bson.M{
+				"allergens":      allergens,
+				"diets":          diets,
+				"classifiedWith": classifier.Version,
+			}},
+		)
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	if updated > 0 {
+		log.Printf("Classified recipes: %d", updated)
+	}
+	return updated, cursor.Err()
+}
+
 func PrometheusMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		timer := prometheus.NewTimer(httpDuration.WithLabelValues(c.Request.URL.Path))
@@ -169,9 +330,19 @@ func PrometheusMiddleware() gin.HandlerFunc {
 // @externalDocs.description	OpenAPI
 // @externalDocs.url			https://swagger.io/resources/open-api/
 func main() {
+	setup()
+
 	gin.SetMode(gin.DebugMode)
 	r := gin.Default()
+	// serve cleartext HTTP/2 to clients that ask for it, e.g. a sidecar
+	r.UseH2C = conf.Server.H2C
 	r.Use(PrometheusMiddleware())
+	r.Use(middlewares.MaxBodySize(int64(conf.Server.MaxBodyMB) << 20))
+	r.Use(middlewares.Timeout(conf.Request.Timeout, conf.Request.Routes))
+	r.Use(middlewares.Identity(conf.Auth.UserSecret))
+	if conf.Auth.UserSecret == "" {
+		log.Warn("USER_ID_SECRET is not set, X-User-ID headers are ignored and forking is disabled.")
+	}
 
 	// refer to: https://medium.com/pengenpaham/implement-basic-logging-with-gin-and-logrus-5f36fba69b28
 	// r.Use(gin.Recovery())
@@ -183,11 +354,97 @@ func main() {
 		v1.GET("/recipes", recipesHandler.ListRecipes)
 		v1.GET("/recipes/:id", recipesHandler.ListRecipe)
 		v1.GET("/recipes/search", recipesHandler.SearchRecipe)
+		v1.GET("/recipes/export", recipesHandler.ExportRecipes)
+		v1.GET("/recipes/cookable", pantriesHandler.CookableRecipes)
 		v1.POST("/recipes", recipesHandler.NewRecipe)
+		v1.POST("/recipes/import", recipesHandler.ImportRecipe)
+		v1.POST("/recipes/batch", recipesHandler.BatchRecipes)
+		v1.POST("/recipes/lookup", recipesHandler.LookupRecipes)
 		v1.PUT("/recipes/:id", recipesHandler.UpdateRecipe)
 		v1.DELETE("/recipes/:id", recipesHandler.DeleteRecipe)
+		v1.POST("/recipes/:id/fork", recipesHandler.ForkRecipe)
+		v1.GET("/recipes/:id/forks", recipesHandler.ListForks)
+		v1.GET("/recipes/:id/diff", recipesHandler.DiffRecipe)
+		v1.GET("/recipes/:id/similar", recommendationsHandler.SimilarRecipes)
+		v1.POST("/shopping-lists", shoppingListsHandler.NewShoppingList)
+		v1.GET("/shopping-lists/:id", shoppingListsHandler.ListShoppingList)
+		v1.PATCH("/shopping-lists/:id/items/:itemId", shoppingListsHandler.UpdateShoppingListItem)
+		v1.POST("/pantries", pantriesHandler.NewPantry)
+		v1.GET("/pantries/:id", pantriesHandler.ListPantry)
+		v1.PUT("/pantries/:id", pantriesHandler.UpdatePantry)
+		v1.GET("/tags", tagsHandler.ListTags)
 	}
+
+	// admin endpoints are only exposed when credentials are configured
+	if conf.Admin.Username != "" {
+		admin := v1.Group("/admin", gin.BasicAuth(gin.Accounts{
+			conf.Admin.Username: conf.Admin.Password,
+		}))
+		{
+			admin.GET("/tags", tagsHandler.ListTaxonomy)
+			admin.PUT("/tags/:name", tagsHandler.UpdateTag)
+			admin.DELETE("/tags/:name", tagsHandler.DeleteTag)
+			admin.POST("/tags/rename", tagsHandler.RenameTag)
+			admin.POST("/tags/merge", tagsHandler.MergeTags)
+			admin.GET("/cache/keys", cacheHandler.ListCacheKeys)
+			admin.DELETE("/cache", cacheHandler.FlushCache)
+			admin.POST("/cache/warmup", cacheHandler.WarmCache)
+		}
+	} else {
+		log.Warn("ADMIN_USERNAME is not set, admin endpoints are disabled.")
+	}
+	r.GET("/healthz", healthHandler.Live)
+	r.GET("/readyz", healthHandler.Ready)
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.Run(":8080")
+
+	srv, err := server.New(r.Handler(), server.Options{
+		Addr:              conf.Server.Addr,
+		Socket:            conf.Server.Socket,
+		ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+		ReadTimeout:       conf.Server.ReadTimeout,
+		WriteTimeout:      conf.Server.WriteTimeout,
+		IdleTimeout:       conf.Server.IdleTimeout,
+		CertFile:          conf.TLS.CertFile,
+		KeyFile:           conf.TLS.KeyFile,
+		ReloadInterval:    conf.TLS.ReloadInterval,
+		HTTP2:             conf.Server.HTTP2,
+	})
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			log.Fatal(err.Error())
+		}
+	}()
+
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	<-signals.Done()
+	// a second signal exits immediately
+	stop()
+	shutdown(srv)
+}
+
+// shutdown drains the server. Readiness fails first so load balancers
+// stop routing to this replica, then in-flight requests have up to
+// SHUTDOWN_TIMEOUT to finish before the database clients are closed.
+func shutdown(srv *server.Server) {
+	log.Info("Shutting down...")
+	healthHandler.Drain()
+	time.Sleep(conf.Shutdown.Delay)
+
+	drain, cancel := context.WithTimeout(context.Background(), conf.Shutdown.Timeout)
+	defer cancel()
+	if err := srv.Shutdown(drain); err != nil {
+		log.Error(err)
+	}
+	stopBackground()
+	if err := mongoDB.Close(drain); err != nil {
+		log.Error(err)
+	}
+	if err := redisCache.Close(); err != nil {
+		log.Error(err)
+	}
+	log.Info("Server stopped")
 }
@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize limits request bodies to limit bytes. Requests declaring a
+// larger body are rejected with 413; reading past the limit of a body
+// without a length fails, which handlers report as a bad request.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"statusCode": http.StatusRequestEntityTooLarge,
+				"error":      fmt.Sprintf("Request body must not exceed %d bytes.", limit),
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeGrace is how long a route with its own timeout may keep writing
+// after its context is done, to flush what it has or send a 504.
+const writeGrace = 5 * time.Second
+
+// Timeout bounds each request's context, which handlers pass on to
+// MongoDB and Redis, so a slow query is aborted instead of outliving the
+// request. routes overrides the fallback per route, keyed by method and
+// route pattern, e.g. "GET /api/v1/recipes/export". A timeout of 0 leaves
+// the request unbounded.
+//
+// Routes with their own timeout, such as the streaming export, also get
+// a write deadline to match, in place of the server's write timeout.
+func Timeout(fallback time.Duration, routes map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := routes[c.Request.Method+" "+c.FullPath()]
+		if ok {
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout + writeGrace)
+			}
+			// not every ResponseWriter supports deadlines, e.g. in tests
+			http.NewResponseController(c.Writer).SetWriteDeadline(deadline)
+		} else {
+			timeout = fallback
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
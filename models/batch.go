@@ -0,0 +1,31 @@
+package models
+
+type BatchOperation struct {
+	Op     string             `json:"op" binding:"required,oneof=create update delete" example:"update"`
+	ID     string             `json:"id,omitempty" example:"64d236d01af83c4f1209cdcf"`
+	Recipe *UserDefinedRecipe `json:"recipe,omitempty"`
+}
+
+type BatchRequest struct {
+	// Ordered stops at the first failed or not found operation; defaults
+	// to true.
+	Ordered    *bool            `json:"ordered,omitempty" example:"true"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+type BatchResult struct {
+	Index  int    `json:"index" example:"0"`
+	Op     string `json:"op" example:"update"`
+	ID     string `json:"id,omitempty" example:"64d236d01af83c4f1209cdcf"`
+	Status string `json:"status" example:"ok" enums:"ok,not_found,failed,skipped"`
+	Error  string `json:"error,omitempty" example:"name is required"`
+}
+
+type BatchResponse struct {
+	Ordered bool          `json:"ordered" example:"true"`
+	Created int           `json:"created" example:"3"`
+	Updated int           `json:"updated" example:"5"`
+	Deleted int           `json:"deleted" example:"1"`
+	Failed  int           `json:"failed" example:"0"`
+	Results []BatchResult `json:"results"`
+}
@@ -0,0 +1,29 @@
+package models
+
+type CacheKey struct {
+	Key   string `json:"key" example:"recipes:list:id,name|_id:1"`
+	Bytes int64  `json:"bytes" example:"13541"`
+	// TTL is the time left in seconds, including the stale window
+	TTL   float64 `json:"ttl" example:"312.5"`
+	Stale bool    `json:"stale" example:"false"`
+}
+
+type CacheTier struct {
+	Entries int `json:"entries" example:"42"`
+	Bytes   int `json:"bytes" example:"524288"`
+}
+
+type CacheKeys struct {
+	Local CacheTier  `json:"local"`
+	Keys  []CacheKey `json:"keys"`
+}
+
+type CacheFlush struct {
+	Deleted int `json:"deleted" example:"12"`
+}
+
+type CacheWarmup struct {
+	// Keys are the entries cached by the warm-up
+	Keys   []string `json:"keys" example:"tags"`
+	Errors []string `json:"errors"`
+}
@@ -8,3 +8,16 @@ type Error struct {
 type Message struct {
 	Message string `json:"message" example:"message"`
 }
+
+type ImportError struct {
+	Row   int    `json:"row" example:"3"`
+	Error string `json:"error" example:"name is required"`
+}
+
+type ImportReport struct {
+	DryRun  bool          `json:"dryRun" example:"false"`
+	Created int           `json:"created" example:"10"`
+	Updated int           `json:"updated" example:"2"`
+	Failed  int           `json:"failed" example:"1"`
+	Errors  []ImportError `json:"errors"`
+}
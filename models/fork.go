@@ -0,0 +1,16 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type DiffLine struct {
+	Op   string `json:"op" example:"insert" enums:"equal,insert,delete"`
+	Text string `json:"text" example:"Preheat the oven to 400 degrees F."`
+}
+
+type RecipeDiff struct {
+	ID                 primitive.ObjectID `json:"id"`
+	ForkedFrom         primitive.ObjectID `json:"forkedFrom"`
+	AddedIngredients   []string           `json:"addedIngredients"`
+	RemovedIngredients []string           `json:"removedIngredients"`
+	Instructions       []DiffLine         `json:"instructions"`
+}
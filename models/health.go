@@ -0,0 +1,17 @@
+package models
+
+type DependencyHealth struct {
+	Status   string `json:"status" example:"up"`
+	Required bool   `json:"required" example:"true"`
+	// Latency of the ping in milliseconds
+	Latency float64 `json:"latencyMs" example:"1.25"`
+	Version string  `json:"version,omitempty" example:"6.0.8"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type Health struct {
+	// Status is "degraded" while an optional dependency is down and
+	// "failing" while a required one is
+	Status       string                      `json:"status" example:"ok"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserDefinedPantry struct {
+	Name  string   `json:"name" bson:"name" example:"Home"`
+	Items []string `json:"items" bson:"items" binding:"required" example:"rosemary,garlic,olive oil"`
+}
+
+type Pantry struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	Items     []string           `json:"items" bson:"items"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type CookableRecipe struct {
+	Recipe       Recipe   `json:"recipe"`
+	MissingCount int      `json:"missingCount" example:"2"`
+	Missing      []string `json:"missing" example:"panko,shallot"`
+}
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,6 +23,7 @@ type UserDefinedRecipe struct {
 	Fiber        int      `json:"fiber" bson:"fiber"`
 	Sugar        int      `json:"sugar" bson:"sugar"`
 	Protein      int      `json:"protein" bson:"proten"`
+	CookTime     int      `json:"cookTime,omitempty" bson:"cookTime,omitempty" example:"25"`
 }
 
 type Recipe struct {
@@ -35,5 +40,69 @@ type Recipe struct {
 	Fiber        int                `json:"fiber" bson:"fiber"`
 	Sugar        int                `json:"sugar" bson:"sugar"`
 	Protein      int                `json:"protein" bson:"proten"`
-	PublishedAt  time.Time          `json:"publishedAt" bson:"publishedAt"`
+	CookTime     int                `json:"cookTime,omitempty" bson:"cookTime,omitempty" example:"25"`
+	Rating       float64            `json:"rating,omitempty" bson:"rating,omitempty" example:"4.5"`
+	Allergens    []string           `json:"allergens" bson:"allergens"`
+	Diets        []string           `json:"diets" bson:"diets"`
+	// ClassifiedWith is the version of the rules allergens and diets
+	// were derived with.
+	ClassifiedWith string              `json:"-" bson:"classifiedWith,omitempty"`
+	Owner          string              `json:"owner,omitempty" bson:"owner,omitempty"`
+	ForkedFrom     *primitive.ObjectID `json:"forkedFrom,omitempty" bson:"forkedFrom,omitempty"`
+	PublishedAt    time.Time           `json:"publishedAt" bson:"publishedAt"`
+}
+
+type SimilarRecipe struct {
+	Recipe Recipe  `json:"recipe"`
+	Score  float64 `json:"score" example:"0.734"`
+}
+
+// Validate checks the fields every stored recipe must have.
+func (recipe Recipe) Validate() error {
+	if strings.TrimSpace(recipe.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(recipe.Ingredients) == 0 {
+		return errors.New("at least one ingredient is required")
+	}
+	fields := []struct {
+		name  string
+		value int
+	}{
+		{"servings", recipe.Servings}, {"calories", recipe.Calories}, {"fat", recipe.Fat},
+		{"satfat", recipe.SatFat}, {"carbs", recipe.Carbs}, {"fiber", recipe.Fiber},
+		{"sugar", recipe.Sugar}, {"protein", recipe.Protein}, {"cookTime", recipe.CookTime},
+	}
+	for _, field := range fields {
+		if field.value < 0 {
+			return fmt.Errorf("%s must not be negative", field.name)
+		}
+	}
+	if math.IsNaN(recipe.Rating) || math.IsInf(recipe.Rating, 0) || recipe.Rating < 0 || recipe.Rating > 5 {
+		return errors.New("rating must be between 0 and 5")
+	}
+	return nil
+}
+
+type RecipeLookup struct {
+	IDs []string `json:"ids" binding:"required,min=1" example:"64d236d01af83c4f1209cdcf,64d236d01af83c4f1209cdd0"`
+}
+
+type RecipeLookupResult struct {
+	ID     string  `json:"id" example:"64d236d01af83c4f1209cdcf"`
+	Status string  `json:"status" example:"found" enums:"found,not_found,invalid"`
+	Recipe *Recipe `json:"recipe,omitempty"`
+}
+
+// RecipeSummary is the default representation of recipes in list views;
+// the full recipe is only returned by ListRecipe.
+type RecipeSummary struct {
+	ID          primitive.ObjectID `json:"id"`
+	Name        string             `json:"name"`
+	Tags        []string           `json:"tags"`
+	Servings    int                `json:"servings"`
+	Calories    int                `json:"calories"`
+	Allergens   []string           `json:"allergens"`
+	Diets       []string           `json:"diets"`
+	PublishedAt time.Time          `json:"publishedAt"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidateRating(t *testing.T) {
+	for _, tc := range []struct {
+		rating float64
+		valid  bool
+	}{
+		{0, true},
+		{4.5, true},
+		{5, true},
+		{-1, false},
+		{5.5, false},
+		{math.NaN(), false},
+		{math.Inf(1), false},
+		{math.Inf(-1), false},
+	} {
+		recipe := Recipe{Name: "Pancakes", Ingredients: []string{"1 cup flour"}, Rating: tc.rating}
+		if err := recipe.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Validate() with rating %v = %v, want valid %v", tc.rating, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RecipeServings struct {
+	RecipeID string `json:"recipeId" binding:"required" example:"64d236d01af83c4f1209cdcf"`
+	Servings int    `json:"servings" example:"4"`
+}
+
+type MealPlanEntry struct {
+	Day      string `json:"day" example:"monday"`
+	Meal     string `json:"meal" example:"dinner"`
+	RecipeID string `json:"recipeId" binding:"required" example:"64d236d01af83c4f1209cdcf"`
+	Servings int    `json:"servings" example:"4"`
+}
+
+type ShoppingListRequest struct {
+	Name     string           `json:"name" example:"Weekly groceries"`
+	Recipes  []RecipeServings `json:"recipes"`
+	MealPlan []MealPlanEntry  `json:"mealPlan"`
+}
+
+type ShoppingListItem struct {
+	ID       primitive.ObjectID   `json:"id" bson:"_id"`
+	Name     string               `json:"name" bson:"name"`
+	Quantity float64              `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	Unit     string               `json:"unit,omitempty" bson:"unit,omitempty"`
+	Recipes  []primitive.ObjectID `json:"recipes" bson:"recipes"`
+	Checked  bool                 `json:"checked" bson:"checked"`
+}
+
+type ShoppingListAisle struct {
+	Name  string             `json:"name" bson:"name"`
+	Items []ShoppingListItem `json:"items" bson:"items"`
+}
+
+type ShoppingList struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id"`
+	Name      string              `json:"name" bson:"name"`
+	Aisles    []ShoppingListAisle `json:"aisles" bson:"aisles"`
+	CreatedAt time.Time           `json:"createdAt" bson:"createdAt"`
+}
+
+type ShoppingListItemState struct {
+	Checked bool `json:"checked" example:"true"`
+}
@@ -0,0 +1,27 @@
+package models
+
+type Tag struct {
+	Name    string   `json:"name" bson:"_id" example:"shrimp"`
+	Aliases []string `json:"aliases" bson:"aliases" example:"prawns"`
+	Parent  string   `json:"parent,omitempty" bson:"parent,omitempty" example:"seafood"`
+}
+
+type UserDefinedTag struct {
+	Aliases []string `json:"aliases" example:"prawns"`
+	Parent  string   `json:"parent" example:"seafood"`
+}
+
+type TagCount struct {
+	Name  string `json:"name" bson:"_id" example:"main"`
+	Count int    `json:"count" bson:"count" example:"230"`
+}
+
+type TagRename struct {
+	From string `json:"from" binding:"required" example:"Main"`
+	To   string `json:"to" binding:"required" example:"main"`
+}
+
+type TagMerge struct {
+	Sources []string `json:"sources" binding:"required" example:"mains,Main"`
+	Target  string   `json:"target" binding:"required" example:"main"`
+}
@@ -0,0 +1,8 @@
+set terminal png
+set output "payload-benchmark.png"
+set title "Pre-encoded cache benchmark"
+set size 1,0.7
+set grid y
+set xlabel "request"
+set ylabel "response time (ms)"
+plot "with-cache.data" using 9 smooth sbezier with lines title "with cache (baseline)", "with-encoded-cache.data" using 9 smooth sbezier with lines title "with pre-encoded cache", "with-encoded-cache-gzip.data" using 9 smooth sbezier with lines title "with pre-encoded cache (gzip)"
@@ -0,0 +1,302 @@
+package recommend
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/wtlow003/recipe-gin-api/ingredients"
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// how much each signal contributes to the final score
+const (
+	ingredientWeight = 0.6
+	tagWeight        = 0.25
+	nutritionWeight  = 0.15
+)
+
+type vector map[string]float64
+
+type document struct {
+	ingredients vector
+	tags        vector
+	nutrition   []float64
+}
+
+// Match is a recipe similar to the one queried and its score in [0, 1].
+type Match struct {
+	ID    primitive.ObjectID
+	Score float64
+}
+
+// stats are the collection-wide figures documents are weighted with.
+type stats struct {
+	total           float64
+	ingredientTerms map[string]int
+	tagTerms        map[string]int
+	mean, std       []float64
+}
+
+// Index scores recipe similarity from TF-IDF vectors over normalized
+// ingredient names and tags, plus a standardized nutrition profile. It
+// is rebuilt from the whole collection and swapped in atomically.
+type Index struct {
+	mu        sync.RWMutex
+	documents map[primitive.ObjectID]document
+	stats     stats
+}
+
+func NewIndex() *Index {
+	return &Index{documents: make(map[primitive.ObjectID]document)}
+}
+
+// Similar returns up to n recipes most similar to id, best first.
+func (index *Index) Similar(id primitive.ObjectID, n int) ([]Match, bool) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	target, ok := index.documents[id]
+	if !ok {
+		return nil, false
+	}
+	return index.rank(id, target, n), true
+}
+
+// SimilarTo returns up to n indexed recipes most similar to a recipe
+// that is not indexed yet, e.g. one created since the last rebuild,
+// weighted with the figures of that rebuild.
+func (index *Index) SimilarTo(recipe models.Recipe, n int) []Match {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	ingredientTerms, tagTerms := terms(recipe)
+	nutrition := profile(recipe)
+	if index.stats.mean != nil {
+		scale(nutrition, index.stats.mean, index.stats.std)
+	}
+	target := document{
+		ingredients: weigh(ingredientTerms, index.stats.ingredientTerms, index.stats.total),
+		tags:        weigh(tagTerms, index.stats.tagTerms, index.stats.total),
+		nutrition:   nutrition,
+	}
+	return index.rank(recipe.ID, target, n)
+}
+
+// rank scores every indexed recipe but id against target. The caller
+// holds the read lock.
+func (index *Index) rank(id primitive.ObjectID, target document, n int) []Match {
+	matches := make([]Match, 0, len(index.documents))
+	for other, doc := range index.documents {
+		if other == id {
+			continue
+		}
+		score := ingredientWeight*cosine(target.ingredients, doc.ingredients) +
+			tagWeight*cosine(target.tags, doc.tags) +
+			nutritionWeight*closeness(target.nutrition, doc.nutrition)
+		matches = append(matches, Match{ID: other, Score: math.Round(score*1000) / 1000})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID.Hex() < matches[j].ID.Hex()
+	})
+	if len(matches) > n {
+		matches = matches[:n]
+	}
+	return matches
+}
+
+// Rebuild recomputes the index from every recipe in the collection.
+func (index *Index) Rebuild(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	recipes := make([]models.Recipe, 0)
+	if err := cursor.All(ctx, &recipes); err != nil {
+		return err
+	}
+
+	documents, stats := build(recipes)
+	index.mu.Lock()
+	index.documents = documents
+	index.stats = stats
+	index.mu.Unlock()
+	log.Printf("Rebuilt similarity index: %d recipes", len(documents))
+	return nil
+}
+
+// RebuildEvery rebuilds the index on a fixed interval until ctx is done.
+func (index *Index) RebuildEvery(ctx context.Context, collection *mongo.Collection, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := index.Rebuild(ctx, collection); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
+func build(recipes []models.Recipe) (map[primitive.ObjectID]document, stats) {
+	ingredientTerms := make([][]string, len(recipes))
+	tagTerms := make([][]string, len(recipes))
+	profiles := make([][]float64, len(recipes))
+	for i, recipe := range recipes {
+		ingredientTerms[i], tagTerms[i] = terms(recipe)
+		profiles[i] = profile(recipe)
+	}
+
+	stats := stats{
+		total:           float64(len(recipes)),
+		ingredientTerms: frequencies(ingredientTerms),
+		tagTerms:        frequencies(tagTerms),
+	}
+	stats.mean, stats.std = standardize(profiles)
+
+	documents := make(map[primitive.ObjectID]document, len(recipes))
+	for i, recipe := range recipes {
+		documents[recipe.ID] = document{
+			ingredients: weigh(ingredientTerms[i], stats.ingredientTerms, stats.total),
+			tags:        weigh(tagTerms[i], stats.tagTerms, stats.total),
+			nutrition:   profiles[i],
+		}
+	}
+	return documents, stats
+}
+
+// terms are the normalized ingredient names and the tags of a recipe.
+func terms(recipe models.Recipe) (ingredientTerms []string, tagTerms []string) {
+	for _, line := range recipe.Ingredients {
+		if ing := ingredients.Parse(line); ing.Valid() {
+			ingredientTerms = append(ingredientTerms, ing.Name)
+		}
+	}
+	for _, tag := range recipe.Tags {
+		tagTerms = append(tagTerms, strings.ToLower(tag))
+	}
+	return ingredientTerms, tagTerms
+}
+
+// profile is the per-serving nutrition of a recipe.
+func profile(recipe models.Recipe) []float64 {
+	servings := float64(recipe.Servings)
+	if servings <= 0 {
+		servings = 1
+	}
+	values := []int{recipe.Calories, recipe.Fat, recipe.SatFat, recipe.Carbs, recipe.Fiber, recipe.Sugar, recipe.Protein}
+	p := make([]float64, len(values))
+	for i, v := range values {
+		p[i] = float64(v) / servings
+	}
+	return p
+}
+
+// frequencies counts the documents each term appears in.
+func frequencies(documents [][]string) map[string]int {
+	frequency := make(map[string]int)
+	for _, terms := range documents {
+		seen := make(map[string]bool)
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				frequency[term]++
+			}
+		}
+	}
+	return frequency
+}
+
+// weigh builds the TF-IDF vector of a document's terms, weighting each
+// by how rare it is across the total documents, normalized to unit
+// length. Terms no document had count as appearing once.
+func weigh(terms []string, frequency map[string]int, total float64) vector {
+	v := make(vector)
+	for _, term := range terms {
+		v[term]++
+	}
+	var norm float64
+	for term, tf := range v {
+		df := math.Max(float64(frequency[term]), 1)
+		weight := tf * math.Log(1+math.Max(total, 1)/df)
+		v[term] = weight
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	for term := range v {
+		v[term] /= norm
+	}
+	return v
+}
+
+// standardize rescales every column to zero mean and unit variance so no
+// single nutrient dominates the distance, and returns the mean and
+// standard deviation of each column.
+func standardize(rows [][]float64) (mean []float64, std []float64) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	mean = make([]float64, len(rows[0]))
+	std = make([]float64, len(rows[0]))
+	for col := range rows[0] {
+		for _, row := range rows {
+			mean[col] += row[col]
+		}
+		mean[col] /= float64(len(rows))
+		var variance float64
+		for _, row := range rows {
+			variance += (row[col] - mean[col]) * (row[col] - mean[col])
+		}
+		std[col] = math.Sqrt(variance / float64(len(rows)))
+	}
+	for _, row := range rows {
+		scale(row, mean, std)
+	}
+	return mean, std
+}
+
+// scale standardizes a profile with the given column statistics.
+func scale(row []float64, mean []float64, std []float64) {
+	for col := range row {
+		if std[col] == 0 {
+			row[col] = 0
+		} else {
+			row[col] = (row[col] - mean[col]) / std[col]
+		}
+	}
+}
+
+// cosine of two unit vectors.
+func cosine(a, b vector) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	return dot
+}
+
+// closeness maps the euclidean distance between two profiles into (0, 1].
+func closeness(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return 1 / (1 + math.Sqrt(sum))
+}
@@ -0,0 +1,113 @@
+package schemaorg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+// schema.org RestrictedDiet values for the diets the classifier assigns
+var suitableForDiet = map[string]string{
+	"vegetarian":  "https://schema.org/VegetarianDiet",
+	"vegan":       "https://schema.org/VeganDiet",
+	"gluten-free": "https://schema.org/GlutenFreeDiet",
+}
+
+type Recipe struct {
+	Context            string      `json:"@context"`
+	Type               string      `json:"@type"`
+	Name               string      `json:"name"`
+	DatePublished      string      `json:"datePublished,omitempty"`
+	Keywords           string      `json:"keywords,omitempty"`
+	RecipeYield        string      `json:"recipeYield,omitempty"`
+	CookTime           string      `json:"cookTime,omitempty"`
+	RecipeIngredient   []string    `json:"recipeIngredient"`
+	RecipeInstructions []HowToStep `json:"recipeInstructions"`
+	Nutrition          *Nutrition  `json:"nutrition,omitempty"`
+	SuitableForDiet    []string    `json:"suitableForDiet,omitempty"`
+}
+
+type HowToStep struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+type Nutrition struct {
+	Type                string `json:"@type"`
+	Calories            string `json:"calories"`
+	FatContent          string `json:"fatContent"`
+	SaturatedFatContent string `json:"saturatedFatContent"`
+	CarbohydrateContent string `json:"carbohydrateContent"`
+	FiberContent        string `json:"fiberContent"`
+	SugarContent        string `json:"sugarContent"`
+	ProteinContent      string `json:"proteinContent"`
+}
+
+// FromRecipe maps a recipe onto a schema.org Recipe for JSON-LD embedding.
+func FromRecipe(recipe models.Recipe) Recipe {
+	out := Recipe{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               recipe.Name,
+		Keywords:           strings.Join(recipe.Tags, ", "),
+		RecipeIngredient:   Ingredients(recipe.Ingredients),
+		RecipeInstructions: make([]HowToStep, 0),
+	}
+	if recipe.CookTime > 0 {
+		out.CookTime = "PT" + strconv.Itoa(recipe.CookTime) + "M"
+	}
+	if !recipe.PublishedAt.IsZero() {
+		out.DatePublished = recipe.PublishedAt.Format("2006-01-02")
+	}
+	for _, step := range Steps(recipe.Instructions) {
+		out.RecipeInstructions = append(out.RecipeInstructions, HowToStep{Type: "HowToStep", Text: step})
+	}
+	for _, diet := range recipe.Diets {
+		if url, ok := suitableForDiet[diet]; ok {
+			out.SuitableForDiet = append(out.SuitableForDiet, url)
+		}
+	}
+
+	// recipes store totals whereas schema.org nutrition is per serving
+	if recipe.Servings > 0 {
+		out.RecipeYield = strconv.Itoa(recipe.Servings) + " servings"
+		perServing := func(total int, unit string) string {
+			return strconv.Itoa(total/recipe.Servings) + " " + unit
+		}
+		out.Nutrition = &Nutrition{
+			Type:                "NutritionInformation",
+			Calories:            perServing(recipe.Calories, "calories"),
+			FatContent:          perServing(recipe.Fat, "g"),
+			SaturatedFatContent: perServing(recipe.SatFat, "g"),
+			CarbohydrateContent: perServing(recipe.Carbs, "g"),
+			FiberContent:        perServing(recipe.Fiber, "g"),
+			SugarContent:        perServing(recipe.Sugar, "g"),
+			ProteinContent:      perServing(recipe.Protein, "g"),
+		}
+	}
+	return out
+}
+
+// Ingredients trims the ingredient lines and drops the "<hr>" separators
+// used in the seed data.
+func Ingredients(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "<") {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// Steps splits free-text instructions into one step per paragraph.
+func Steps(instructions string) []string {
+	steps := make([]string, 0)
+	for _, line := range strings.Split(instructions, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			steps = append(steps, line)
+		}
+	}
+	return steps
+}
@@ -0,0 +1,269 @@
+package schemaorg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/wtlow003/recipe-gin-api/models"
+)
+
+var ErrNoRecipe = errors.New("no schema.org Recipe found in document")
+
+// ExtractJSONLD returns the contents of every
+// <script type="application/ld+json"> element in an HTML document.
+func ExtractJSONLD(document []byte) ([][]byte, error) {
+	root, err := html.Parse(bytes.NewReader(document))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([][]byte, 0)
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "script" && isJSONLD(node) {
+			var b bytes.Buffer
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				if child.Type == html.TextNode {
+					b.WriteString(child.Data)
+				}
+			}
+			blocks = append(blocks, b.Bytes())
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return blocks, nil
+}
+
+func isJSONLD(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == "type" && strings.EqualFold(strings.TrimSpace(attr.Val), "application/ld+json") {
+			return true
+		}
+	}
+	return false
+}
+
+// RecipeFromHTML finds the first schema.org Recipe embedded in an HTML
+// document and maps it onto a recipe.
+func RecipeFromHTML(document []byte) (models.Recipe, error) {
+	blocks, err := ExtractJSONLD(document)
+	if err != nil {
+		return models.Recipe{}, err
+	}
+	for _, block := range blocks {
+		recipe, err := RecipeFromJSONLD(block)
+		if err == nil {
+			return recipe, nil
+		}
+	}
+	return models.Recipe{}, ErrNoRecipe
+}
+
+// RecipeFromJSONLD maps a JSON-LD document onto a recipe. The document
+// may be a single Recipe node, an array of nodes or an object with an
+// @graph, as commonly emitted by publishing platforms.
+func RecipeFromJSONLD(document []byte) (models.Recipe, error) {
+	var data interface{}
+	if err := json.Unmarshal(document, &data); err != nil {
+		return models.Recipe{}, err
+	}
+	node := findRecipe(data)
+	if node == nil {
+		return models.Recipe{}, ErrNoRecipe
+	}
+
+	recipe := models.Recipe{
+		Name:         cleanText(text(node["name"])),
+		Ingredients:  stringList(node["recipeIngredient"]),
+		Instructions: strings.Join(instructions(node["recipeInstructions"]), "\r\n\r\n"),
+		Tags:         keywords(node["keywords"]),
+		Servings:     number(node["recipeYield"]),
+	}
+	recipe.CookTime = minutes(text(node["cookTime"]))
+	if rating, ok := node["aggregateRating"].(map[string]interface{}); ok {
+		recipe.Rating = amount(rating["ratingValue"])
+	}
+	// older markup uses "ingredients"
+	if len(recipe.Ingredients) == 0 {
+		recipe.Ingredients = stringList(node["ingredients"])
+	}
+
+	// schema.org nutrition is per serving whereas recipes store totals
+	if nutrition, ok := node["nutrition"].(map[string]interface{}); ok {
+		servings := float64(recipe.Servings)
+		if servings <= 0 {
+			servings = 1
+		}
+		total := func(key string) int {
+			return int(math.Round(amount(nutrition[key]) * servings))
+		}
+		recipe.Calories = total("calories")
+		recipe.Fat = total("fatContent")
+		recipe.SatFat = total("saturatedFatContent")
+		recipe.Carbs = total("carbohydrateContent")
+		recipe.Fiber = total("fiberContent")
+		recipe.Sugar = total("sugarContent")
+		recipe.Protein = total("proteinContent")
+	}
+	return recipe, nil
+}
+
+func findRecipe(data interface{}) map[string]interface{} {
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if node := findRecipe(item); node != nil {
+				return node
+			}
+		}
+	case map[string]interface{}:
+		if isType(value["@type"], "Recipe") {
+			return value
+		}
+		if graph, ok := value["@graph"]; ok {
+			return findRecipe(graph)
+		}
+	}
+	return nil
+}
+
+func isType(value interface{}, want string) bool {
+	switch t := value.(type) {
+	case string:
+		return t == want
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// instructions flattens recipeInstructions, which may be plain text, a
+// list of strings, HowToStep nodes or HowToSection nodes holding steps.
+func instructions(value interface{}) []string {
+	steps := make([]string, 0)
+	switch v := value.(type) {
+	case string:
+		if s := cleanText(v); s != "" {
+			steps = append(steps, s)
+		}
+	case []interface{}:
+		for _, item := range v {
+			steps = append(steps, instructions(item)...)
+		}
+	case map[string]interface{}:
+		if elements, ok := v["itemListElement"]; ok {
+			return instructions(elements)
+		}
+		if s := cleanText(text(v["text"])); s != "" {
+			steps = append(steps, s)
+		} else if s := cleanText(text(v["name"])); s != "" {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+func keywords(value interface{}) []string {
+	tags := make([]string, 0)
+	for _, item := range stringList(value) {
+		for _, keyword := range strings.Split(item, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				tags = append(tags, keyword)
+			}
+		}
+	}
+	return tags
+}
+
+// stringList accepts a string or a list of strings.
+func stringList(value interface{}) []string {
+	values := make([]string, 0)
+	switch v := value.(type) {
+	case string:
+		if s := cleanText(v); s != "" {
+			values = append(values, s)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s := cleanText(text(item)); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if len(v) > 0 {
+			return text(v[0])
+		}
+	}
+	return ""
+}
+
+var leadingNumber = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// amount reads the first number out of values such as 4, "4 servings"
+// or "9.5 g".
+func amount(value interface{}) float64 {
+	if f, ok := value.(float64); ok {
+		return f
+	}
+	match := leadingNumber.FindString(text(value))
+	if match == "" {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(match, 64)
+	return f
+}
+
+func number(value interface{}) int {
+	return int(math.Round(amount(value)))
+}
+
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// minutes reads an ISO 8601 duration such as PT1H30M, rounded to whole
+// minutes.
+func minutes(duration string) int {
+	match := isoDuration.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(duration)))
+	if match == nil {
+		return 0
+	}
+	var total float64
+	for i, scale := range []float64{24 * 60, 60, 1, 1.0 / 60} {
+		if match[i+1] != "" {
+			n, _ := strconv.ParseFloat(match[i+1], 64)
+			total += n * scale
+		}
+	}
+	return int(math.Round(total))
+}
+
+var markup = regexp.MustCompile(`<[^>]*>`)
+
+// cleanText strips markup some publishers leave inside JSON-LD strings.
+func cleanText(s string) string {
+	s = markup.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
@@ -0,0 +1,48 @@
+package schemaorg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecipeFromJSONLDRating(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		rating  string
+		want    float64
+		invalid bool
+	}{
+		{"number", `4.5`, 4.5, false},
+		{"text", `"4 stars"`, 4, false},
+		{"above five", `7`, 7, true},
+		// too many digits for a float64, so it parses as +Inf
+		{"overflow", `"` + strings.Repeat("9", 400) + `"`, 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			document := `{
+				"@context": "https://schema.org",
+				"@type": "Recipe",
+				"name": "Pancakes",
+				"recipeIngredient": ["1 cup flour"],
+				"aggregateRating": {"@type": "AggregateRating", "ratingValue": ` + tc.rating + `}
+			}`
+			recipe, err := RecipeFromJSONLD([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = recipe.Validate()
+			if tc.invalid {
+				if err == nil {
+					t.Errorf("Validate() accepted rating %v", recipe.Rating)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+			if recipe.Rating != tc.want {
+				t.Errorf("Rating = %v, want %v", recipe.Rating, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Options configures how the API is served.
+type Options struct {
+	// Addr is the TCP address to listen on, unless Socket is set.
+	Addr string
+	// Socket is a Unix socket path to listen on instead of Addr, e.g.
+	// behind a sidecar proxy.
+	Socket string
+
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// CertFile and KeyFile enable TLS. They are checked for changes every
+	// ReloadInterval, so renewed certificates are picked up without a
+	// restart.
+	CertFile       string
+	KeyFile        string
+	ReloadInterval time.Duration
+	// HTTP2 enables HTTP/2 over TLS.
+	HTTP2 bool
+}
+
+// Server serves the API over TCP or a Unix socket, with optional TLS.
+type Server struct {
+	http    *http.Server
+	options Options
+	cert    *certificate
+	done    chan struct{}
+}
+
+// New returns a server for handler. With TLS enabled, the certificate is
+// loaded up front so a bad one fails at startup.
+func New(handler http.Handler, options Options) (*Server, error) {
+	server := &Server{
+		http: &http.Server{
+			Addr:              options.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: options.ReadHeaderTimeout,
+			ReadTimeout:       options.ReadTimeout,
+			WriteTimeout:      options.WriteTimeout,
+			IdleTimeout:       options.IdleTimeout,
+		},
+		options: options,
+		done:    make(chan struct{}),
+	}
+	if !options.HTTP2 {
+		// a non-nil map stops net/http from negotiating HTTP/2
+		server.http.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	if server.tls() {
+		cert, err := loadCertificate(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		server.cert = cert
+		server.http.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: cert.get,
+		}
+	}
+	return server, nil
+}
+
+func (server *Server) tls() bool {
+	return server.options.CertFile != "" || server.options.KeyFile != ""
+}
+
+// ListenAndServe serves until Shutdown, then returns nil.
+func (server *Server) ListenAndServe() error {
+	listener, err := server.listen()
+	if err != nil {
+		return err
+	}
+	if server.tls() {
+		if server.options.ReloadInterval > 0 {
+			go server.cert.watch(server.options.ReloadInterval, server.done)
+		}
+		err = server.http.ServeTLS(listener, "", "")
+	} else {
+		err = server.http.Serve(listener)
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+func (server *Server) listen() (net.Listener, error) {
+	if server.options.Socket == "" {
+		log.Infof("Listening on %s", server.options.Addr)
+		return net.Listen("tcp", server.options.Addr)
+	}
+	// a socket left behind by a previous run would fail the listen
+	if err := os.Remove(server.options.Socket); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	log.Infof("Listening on unix:%s", server.options.Socket)
+	return net.Listen("unix", server.options.Socket)
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests
+// until ctx is done.
+func (server *Server) Shutdown(ctx context.Context) error {
+	close(server.done)
+	return server.http.Shutdown(ctx)
+}
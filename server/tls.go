@@ -0,0 +1,95 @@
+package server
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// certificate holds the TLS certificate served, reloading it when its
+// files change.
+type certificate struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modified time.Time
+}
+
+func loadCertificate(certFile, keyFile string) (*certificate, error) {
+	cert := &certificate{certFile: certFile, keyFile: keyFile}
+	if _, err := cert.reload(); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+func (cert *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert.mu.RLock()
+	defer cert.mu.RUnlock()
+	return cert.cert, nil
+}
+
+// reload loads the certificate if either file changed since it was last
+// loaded, and reports whether it did.
+func (cert *certificate) reload() (bool, error) {
+	modified, err := latestModification(cert.certFile, cert.keyFile)
+	if err != nil {
+		return false, err
+	}
+	cert.mu.RLock()
+	unchanged := !modified.After(cert.modified)
+	cert.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	loaded, err := tls.LoadX509KeyPair(cert.certFile, cert.keyFile)
+	if err != nil {
+		return false, err
+	}
+	cert.mu.Lock()
+	defer cert.mu.Unlock()
+	cert.cert = &loaded
+	cert.modified = modified
+	return true, nil
+}
+
+// watch reloads the certificate every interval until done is closed. A
+// certificate that fails to load, e.g. while it is being replaced, is
+// retried at the next check and the previous one kept meanwhile.
+func (cert *certificate) watch(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			reloaded, err := cert.reload()
+			if err != nil {
+				log.Error(err)
+			} else if reloaded {
+				log.Info("Reloaded TLS certificate")
+			}
+		}
+	}
+}
+
+func latestModification(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}